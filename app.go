@@ -2,49 +2,161 @@ package main
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/keystore"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/hashicorp/vault/shamir"
+	"github.com/ethereum/go-ethereum/common"
+
+	"key-generator/keygen"
+	"key-generator/keystorefmt"
+	"key-generator/sharing"
+	"key-generator/slip39"
 )
 
 // App struct
 type App struct {
 	ctx context.Context
+	kdf keystorefmt.KDF // key derivation of new keystores
 }
 
-// KeyResult represents the result of key generation
+// shareModeKeystore stands in for the keystore of share mode results, which
+// only have per-share keystores (공유 키 모드에서는 키스토어를 생성하지 않음)
+const shareModeKeystore = "공유 키 모드에서는 개별 공유 키를 다운로드하세요."
+
+// KeyResult represents the result of key generation. KDF reports the key
+// derivation of a keystore created by the call.
 type KeyResult struct {
-	Keystore   string `json:"keystore"`
-	PublicKey  string `json:"publicKey"`
-	PrivateKey string `json:"privateKey"`
-	Address    string `json:"address"`
+	Keystore   string     `json:"keystore"`
+	PublicKey  string     `json:"publicKey"`
+	PrivateKey string     `json:"privateKey"`
+	Address    string     `json:"address"`
+	KDF        *KDFResult `json:"kdf,omitempty"`
+}
+
+// KDFResult represents the key derivation profile and parameters of a keystore.
+// N and P are set for scrypt and Iterations for pbkdf2.
+type KDFResult struct {
+	Profile     string `json:"profile"`
+	Function    string `json:"function"`
+	Description string `json:"description"`
+	N           int    `json:"n,omitempty"`
+	R           int    `json:"r,omitempty"`
+	P           int    `json:"p,omitempty"`
+	Iterations  int    `json:"iterations,omitempty"`
 }
 
-// ShamirResult represents the result of Shamir secret sharing
+// MnemonicResult represents a key derived from a BIP-39 mnemonic
+type MnemonicResult struct {
+	Mnemonic string `json:"mnemonic"`
+	Path     string `json:"path"`
+	KeyResult
+}
+
+// DerivedKeyResult represents one account derived along an HD path
+type DerivedKeyResult struct {
+	Path  string `json:"path"`
+	Index int    `json:"index"`
+	KeyResult
+}
+
+// ShamirResult represents the result of Shamir secret sharing. Every share is
+// given as hex and as checksummed words for paper storage, together with its
+// index in the set. Groups is the group of every share of a group policy.
+// Mnemonic and Path are only set when a mnemonic was split;
+// the key is its first account.
 type ShamirResult struct {
-	Shares []string `json:"shares"`
+	Shares     []string       `json:"shares"`
+	ShareWords []string       `json:"shareWords"`
+	Indexes    []int          `json:"indexes"`
+	Groups     []int          `json:"groups,omitempty"`
+	ShareSet   ShareSetResult `json:"shareSet"`
+	Mnemonic   string         `json:"mnemonic,omitempty"`
+	Path       string         `json:"path,omitempty"`
 	KeyResult
 }
 
-// ShareKeystoreResult represents a single share keystore
+// RecoveredSecretResult represents a secret recovered from share keystores.
+// Mnemonic is set for mnemonic entropy shares and Seed for seed shares.
+type RecoveredSecretResult struct {
+	Kind     string `json:"kind"`
+	Mnemonic string `json:"mnemonic,omitempty"`
+	Seed     string `json:"seed,omitempty"`
+	Path     string `json:"path,omitempty"`
+	KeyResult
+}
+
+// SLIP39Group is the member threshold and member count of one SLIP-39 group
+type SLIP39Group struct {
+	Threshold int `json:"threshold"`
+	Count     int `json:"count"`
+}
+
+// SLIP39Result represents a master secret split into SLIP-39 mnemonic shares.
+// The key is the first account of the master secret used as a BIP-32 seed.
+type SLIP39Result struct {
+	Groups         [][]string `json:"groups"`
+	GroupThreshold int        `json:"groupThreshold"`
+	Path           string     `json:"path"`
+	KeyResult
+}
+
+// PolicyGroup is the name, member threshold and member count of one group of
+// a group policy
+type PolicyGroup struct {
+	Name      string `json:"name"`
+	Threshold int    `json:"threshold"`
+	Count     int    `json:"count"`
+}
+
+// PolicyResult represents the group policy of a nested split
+type PolicyResult struct {
+	Description string        `json:"description"`
+	Threshold   int           `json:"threshold"`
+	Groups      []PolicyGroup `json:"groups"`
+}
+
+// ShareSetResult represents the metadata of one split, carried by every share.
+// Commitments are only set for verifiable shares and Policy for group policies.
+type ShareSetResult struct {
+	SetID       string        `json:"setId"`
+	Kind        string        `json:"kind"`
+	Address     string        `json:"address"`
+	Threshold   int           `json:"threshold"`
+	Total       int           `json:"total"`
+	CreatedAt   string        `json:"createdAt"`
+	Version     int           `json:"version"`
+	Commitments []string      `json:"commitments,omitempty"`
+	Coordinates []int         `json:"coordinates,omitempty"`
+	Policy      *PolicyResult `json:"policy,omitempty"`
+}
+
+// ShareKeystoreResult represents a single share keystore. KDF reports the key
+// derivation of a password share keystore and is nil for custodian shares.
 type ShareKeystoreResult struct {
-	Keystore string `json:"keystore"`
-	Index    int    `json:"index"`
+	Keystore string     `json:"keystore"`
+	Index    int        `json:"index"`
+	KDF      *KDFResult `json:"kdf,omitempty"`
+}
+
+// DecryptedShareResult represents a share recovered from a share keystore
+type DecryptedShareResult struct {
+	Share      string          `json:"share"`
+	ShareIndex int             `json:"shareIndex"`
+	ShareGroup int             `json:"shareGroup,omitempty"`
+	Address    string          `json:"address"`
+	ShareSet   *ShareSetResult `json:"shareSet"`
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	return &App{kdf: keystorefmt.KDFStandard}
 }
 
 // startup is called when the app starts. The context is saved
@@ -108,156 +220,495 @@ func (a *App) getDownloadPath() string {
 	}
 }
 
+// SetKDFProfile selects the key derivation of keystores created afterwards:
+// light for test keys, standard, paranoid for cold storage or pbkdf2 for
+// consumers that cannot do scrypt
+func (a *App) SetKDFProfile(profile string) (*KDFResult, error) {
+	kdf, err := keystorefmt.ParseKDF(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	a.kdf = kdf
+	return newKDFResult(kdf), nil
+}
+
 // GenerateKey generates a single EVM key pair
 func (a *App) GenerateKey(password string) (*KeyResult, error) {
-	// Generate private key
-	privateKey, err := crypto.GenerateKey()
+	key, err := keygen.Generate()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate private key: %v", err)
+		return nil, err
 	}
 
-	// Get public key
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("failed to get public key")
+	return a.newKeystoreResult(key, password)
+}
+
+// GenerateMnemonicKey generates a 12 or 24 word BIP-39 mnemonic and the key of
+// its first Ethereum account, encrypted into a keystore
+func (a *App) GenerateMnemonicKey(password string, wordCount int, passphrase string) (*MnemonicResult, error) {
+	mnemonic, err := keygen.NewMnemonic(wordCount)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get address
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
+	return a.ImportMnemonic(mnemonic, passphrase, password)
+}
 
-	// Create keystore (simplified version)
-	keystore := createKeystore(privateKey, password)
+// ImportMnemonic derives the key of the first Ethereum account of an existing
+// BIP-39 mnemonic and encrypts it into a keystore
+func (a *App) ImportMnemonic(mnemonic string, passphrase string, password string) (*MnemonicResult, error) {
+	if err := keystorefmt.ValidatePassword(password); err != nil {
+		return nil, err
+	}
 
-	return &KeyResult{
-		Keystore:   keystore,
-		PublicKey:  hex.EncodeToString(crypto.FromECDSAPub(publicKeyECDSA)),
-		PrivateKey: hex.EncodeToString(crypto.FromECDSA(privateKey)),
-		Address:    address.Hex(),
+	key, err := keygen.FromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	result, err := a.newKeystoreResult(key, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MnemonicResult{
+		Mnemonic:  keygen.NormalizeMnemonic(mnemonic),
+		Path:      keygen.DefaultPath.String(),
+		KeyResult: *result,
 	}, nil
 }
 
-// GenerateShamirShares generates Shamir secret sharing for the given number of shares
+// DeriveMnemonicKeys derives count accounts of a BIP-39 mnemonic starting at
+// index start. The last component of basePath is replaced by the account index;
+// an empty basePath selects m/44'/60'/0'/0/i. Keystores are only created when a
+// password is given, so the addresses of a mnemonic can be listed cheaply;
+// listed accounts carry no private key.
+func (a *App) DeriveMnemonicKeys(mnemonic string, passphrase string, basePath string, start int, count int, password string) ([]DerivedKeyResult, error) {
+	if password != "" {
+		if err := keystorefmt.ValidatePassword(password); err != nil {
+			return nil, err
+		}
+	}
+
+	base := keygen.DefaultPath
+	if strings.TrimSpace(basePath) != "" {
+		var err error
+		if base, err = keygen.ParsePath(strings.TrimSpace(basePath)); err != nil {
+			return nil, err
+		}
+	}
+
+	seed, err := keygen.MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := keygen.DeriveRange(seed, base, start, count)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]DerivedKeyResult, len(keys))
+	for i, key := range keys {
+		result := newKeyResult(key.Key, "")
+		result.PrivateKey = ""
+		if password != "" {
+			if result, err = a.newKeystoreResult(key.Key, password); err != nil {
+				return nil, err
+			}
+		}
+		results[i] = DerivedKeyResult{
+			Path:      key.Path.String(),
+			Index:     start + i,
+			KeyResult: *result,
+		}
+	}
+
+	return results, nil
+}
+
+// GenerateShamirShares generates Shamir secret sharing for the given number of
+// shares. Counts outside 2-255 or a threshold outside 2 to totalShares fail
+// with sharing.ErrInvalidShareCount or sharing.ErrInvalidThreshold.
 func (a *App) GenerateShamirShares(password string, totalShares int, threshold int) (*ShamirResult, error) {
-	// Generate private key
-	privateKey, err := crypto.GenerateKey()
+	if err := sharing.ValidateSplit(totalShares, threshold); err != nil {
+		return nil, err
+	}
+
+	key, err := keygen.Generate()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate private key: %v", err)
+		return nil, err
 	}
 
-	// Get public key
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("failed to get public key")
+	shares, err := sharing.SplitKey(key, totalShares, threshold)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get address
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
+	result := newShamirResult(shares)
+	result.KeyResult = *newKeyResult(key, shareModeKeystore)
+	return result, nil
+}
 
-	// Convert private key to bytes
-	privateKeyBytes := crypto.FromECDSA(privateKey)
+// ImportKeystore decrypts an existing V3 keystore with scrypt or pbkdf2 key
+// derivation and returns its address and public key. The private key is left
+// out; RevealKeystorePrivateKey returns it on request.
+func (a *App) ImportKeystore(keystoreJSON string, password string) (*KeyResult, error) {
+	key, err := keystorefmt.DecryptKey([]byte(keystoreJSON), password)
+	if err != nil {
+		return nil, err
+	}
 
-	// Generate Shamir shares with threshold
-	shares, err := shamir.Split(privateKeyBytes, totalShares, threshold)
+	result := newKeyResult(key, keystoreJSON)
+	result.PrivateKey = ""
+	return result, nil
+}
+
+// RevealKeystorePrivateKey decrypts an existing V3 keystore and returns its
+// private key as hex
+func (a *App) RevealKeystorePrivateKey(keystoreJSON string, password string) (string, error) {
+	key, err := keystorefmt.DecryptKey([]byte(keystoreJSON), password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate Shamir shares: %v", err)
+		return "", err
 	}
 
-	// Convert shares to hex strings (for internal use only)
-	shareStrings := make([]string, len(shares))
-	for i, share := range shares {
-		shareStrings[i] = hex.EncodeToString(share)
+	return key.PrivateKeyHex(), nil
+}
+
+// ChangeKeystorePassword re-encrypts an existing V3 keystore under a new
+// password with a fresh salt and IV, keeping its address and UUID, so that a
+// password can be rotated without exporting the private key. An empty
+// kdfProfile or "keep" keeps the key derivation parameters of the keystore.
+// Only the new keystore, the address and the key derivation are returned.
+func (a *App) ChangeKeystorePassword(keystoreJSON string, oldPassword string, newPassword string, kdfProfile string) (*KeyResult, error) {
+	if err := keystorefmt.ValidatePassword(newPassword); err != nil {
+		return nil, err
 	}
 
-	// 공유 키 모드에서는 키스토어를 생성하지 않음
-	// 대신 빈 문자열이나 간단한 메시지 반환
-	keystore := "공유 키 모드에서는 개별 공유 키를 다운로드하세요."
+	var kdf *keystorefmt.KDF
+	if profile := strings.TrimSpace(kdfProfile); profile != "" && profile != "keep" {
+		parsed, err := keystorefmt.ParseKDF(profile)
+		if err != nil {
+			return nil, err
+		}
+		kdf = &parsed
+	}
+
+	reencrypted, err := keystorefmt.ChangePassword([]byte(keystoreJSON), oldPassword, newPassword, kdf)
+	if err != nil {
+		return nil, err
+	}
+	info, err := keystorefmt.Inspect(reencrypted)
+	if err != nil {
+		return nil, err
+	}
+	written, err := keystorefmt.KeystoreKDF(reencrypted)
+	if err != nil {
+		return nil, err
+	}
 
-	return &ShamirResult{
-		Shares: shareStrings,
-		KeyResult: KeyResult{
-			Keystore:   keystore,
-			PublicKey:  hex.EncodeToString(crypto.FromECDSAPub(publicKeyECDSA)),
-			PrivateKey: hex.EncodeToString(privateKeyBytes),
-			Address:    address.Hex(),
-		},
+	return &KeyResult{
+		Keystore: string(reencrypted),
+		Address:  info.Address,
+		KDF:      newKDFResult(written),
 	}, nil
 }
 
-// CombineShamirShares combines Shamir shares to recover the original private key
-func (a *App) CombineShamirShares(shares []string) (*KeyResult, error) {
-	// Convert hex shares to bytes
-	shareBytes := make([][]byte, len(shares))
-	for i, shareHex := range shares {
-		shareBytes[i], _ = hex.DecodeString(shareHex)
+// SplitKeystore decrypts an existing V3 keystore and splits its key into
+// shares like GenerateShamirShares. The private key is left out of the result.
+func (a *App) SplitKeystore(keystoreJSON string, password string, totalShares int, threshold int) (*ShamirResult, error) {
+	if err := sharing.ValidateSplit(totalShares, threshold); err != nil {
+		return nil, err
 	}
 
-	// Combine shares to recover the original private key
-	recoveredBytes, err := shamir.Combine(shareBytes)
+	key, err := keystorefmt.DecryptKey([]byte(keystoreJSON), password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to combine shares: %v", err)
+		return nil, err
+	}
+
+	return splitExistingKey(key, totalShares, threshold)
+}
+
+// SplitPrivateKey splits an existing hex private key, with or without 0x
+// prefix, into shares like GenerateShamirShares, for example to put a
+// treasury key under M-of-N custody. The private key is left out of the result.
+func (a *App) SplitPrivateKey(privateKeyHex string, totalShares int, threshold int) (*ShamirResult, error) {
+	if err := sharing.ValidateSplit(totalShares, threshold); err != nil {
+		return nil, err
 	}
 
-	// Convert recovered bytes to private key
-	privateKey, err := crypto.ToECDSA(recoveredBytes)
+	key, err := keygen.FromHex(privateKeyHex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert recovered bytes to private key: %v", err)
+		return nil, err
 	}
 
-	// Get public key
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("failed to get public key")
+	return splitExistingKey(key, totalShares, threshold)
+}
+
+// splitExistingKey splits a key the caller already holds; unlike for a newly
+// generated key, the private key is not handed back
+func splitExistingKey(key *keygen.Key, totalShares int, threshold int) (*ShamirResult, error) {
+	shares, err := sharing.SplitKey(key, totalShares, threshold)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get address
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
+	result := newShamirResult(shares)
+	result.KeyResult = *newKeyResult(key, shareModeKeystore)
+	result.PrivateKey = ""
+	return result, nil
+}
 
-	// Create keystore (simplified version)
-	keystore := createKeystore(privateKey, "recovered_password")
+// GenerateVerifiableShares generates a new private key and splits it into
+// verifiable shares. The commitments in the share set let every holder check
+// their own share with VerifyShareKeystore.
+func (a *App) GenerateVerifiableShares(totalShares int, threshold int) (*ShamirResult, error) {
+	if err := sharing.ValidateSplit(totalShares, threshold); err != nil {
+		return nil, err
+	}
 
-	return &KeyResult{
-		Keystore:   keystore,
-		PublicKey:  hex.EncodeToString(crypto.FromECDSAPub(publicKeyECDSA)),
-		PrivateKey: hex.EncodeToString(crypto.FromECDSA(privateKey)),
-		Address:    address.Hex(),
+	key, err := keygen.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := sharing.SplitKeyVerifiable(key, totalShares, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newShamirResult(shares)
+	result.KeyResult = *newKeyResult(key, shareModeKeystore)
+	return result, nil
+}
+
+// GeneratePolicyShares generates a new private key and splits it under a group
+// policy: groupThreshold of the groups are needed, each with its own member
+// threshold, for example 2 of 3 executives and 1 of 4 security officers.
+// groupThreshold 0 requires all groups. The shares are checked to recover the
+// key before they are returned.
+func (a *App) GeneratePolicyShares(groupThreshold int, groups []PolicyGroup) (*ShamirResult, error) {
+	policy := newPolicy(groupThreshold, groups)
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	key, err := keygen.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := sharing.SplitSecretPolicy(sharing.KindPrivateKey, key.Bytes(), key.Address, policy)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sharing.RecoverKey(shares); err != nil {
+		return nil, fmt.Errorf("shares do not recover the key: %w", err)
+	}
+
+	result := newShamirResult(shares)
+	result.KeyResult = *newKeyResult(key, shareModeKeystore)
+	return result, nil
+}
+
+// GenerateMnemonicShares generates a 12 or 24 word BIP-39 mnemonic and splits
+// it into shares; see SplitMnemonic
+func (a *App) GenerateMnemonicShares(wordCount int, passphrase string, secretKind string, totalShares int, threshold int) (*ShamirResult, error) {
+	if err := sharing.ValidateSplit(totalShares, threshold); err != nil {
+		return nil, err
+	}
+
+	mnemonic, err := keygen.NewMnemonic(wordCount)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.SplitMnemonic(mnemonic, passphrase, secretKind, totalShares, threshold)
+}
+
+// SplitMnemonic splits a BIP-39 mnemonic into shares so that recovering them
+// brings back the whole account tree. secretKind selects what is split:
+// "mnemonic-entropy" keeps the mnemonic and its passphrase separate, "seed"
+// splits the 64-byte seed with the passphrase already applied.
+func (a *App) SplitMnemonic(mnemonic string, passphrase string, secretKind string, totalShares int, threshold int) (*ShamirResult, error) {
+	kind, err := sharing.ParseSecretKind(secretKind)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := keygen.MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keygen.DeriveKey(seed, keygen.DefaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret []byte
+	switch kind {
+	case sharing.KindMnemonicEntropy:
+		if secret, err = keygen.MnemonicEntropy(mnemonic); err != nil {
+			return nil, err
+		}
+	case sharing.KindSeed:
+		secret = seed
+	default:
+		return nil, fmt.Errorf("a mnemonic can be split as mnemonic-entropy or seed, not %s", kind)
+	}
+
+	shares, err := sharing.SplitSecret(kind, secret, key.Address, totalShares, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newShamirResult(shares)
+	result.Mnemonic = keygen.NormalizeMnemonic(mnemonic)
+	result.Path = keygen.DefaultPath.String()
+	result.KeyResult = *newKeyResult(key, shareModeKeystore)
+	return result, nil
+}
+
+// GenerateSLIP39Shares generates a 128 or 256-bit master secret and splits it
+// into SLIP-39 mnemonic shares that other SLIP-39 wallets can recover. Any
+// groupThreshold groups, each with its member threshold of shares, recover it;
+// the passphrase is needed as well when it is not empty.
+func (a *App) GenerateSLIP39Shares(strength int, passphrase string, groupThreshold int, groups []SLIP39Group) (*SLIP39Result, error) {
+	if strength != 128 && strength != 256 {
+		return nil, fmt.Errorf("SLIP-39 master secret must be 128 or 256 bits, not %d", strength)
+	}
+
+	masterSecret := make([]byte, strength/8)
+	if _, err := rand.Read(masterSecret); err != nil {
+		return nil, fmt.Errorf("failed to generate master secret: %v", err)
+	}
+	key, err := keygen.DeriveKey(masterSecret, keygen.DefaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]slip39.GroupSpec, len(groups))
+	for i, group := range groups {
+		specs[i] = slip39.GroupSpec{Threshold: group.Threshold, Count: group.Count}
+	}
+	mnemonics, err := slip39.Generate(groupThreshold, specs, masterSecret, passphrase, slip39.DefaultIterationExponent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SLIP39Result{
+		Groups:         mnemonics,
+		GroupThreshold: groupThreshold,
+		Path:           keygen.DefaultPath.String(),
+		KeyResult:      *newKeyResult(key, "SLIP-39 공유 키를 각 보관자에게 전달하세요."),
 	}, nil
 }
 
-// CreateShareKeystore creates a keystore for a specific share
-func (a *App) CreateShareKeystore(shareHex string, password string, index int, address string) (*ShareKeystoreResult, error) {
-	// Decode share from hex
-	shareBytes, err := hex.DecodeString(shareHex)
+// RecoverSLIP39Shares combines SLIP-39 mnemonic shares into the master secret
+// and encrypts the key of its first account under password. SLIP-39 does not
+// detect a wrong passphrase; it recovers a different wallet instead.
+func (a *App) RecoverSLIP39Shares(mnemonics []string, passphrase string, password string) (*RecoveredSecretResult, error) {
+	if err := keystorefmt.ValidatePassword(password); err != nil {
+		return nil, err
+	}
+
+	masterSecret, err := slip39.Combine(mnemonics, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keygen.DeriveKey(masterSecret, keygen.DefaultPath)
+	if err != nil {
+		return nil, err
+	}
+	keyResult, err := a.newKeystoreResult(key, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecoveredSecretResult{
+		Kind:      sharing.KindSeed.String(),
+		Seed:      hex.EncodeToString(masterSecret),
+		Path:      keygen.DefaultPath.String(),
+		KeyResult: *keyResult,
+	}, nil
+}
+
+// CombineShamirShares combines Shamir shares, written as hex or as share words,
+// to recover the original private key and encrypts it into a keystore under the
+// given password. A missing or weak password fails with a
+// *keystorefmt.PasswordPolicyError.
+func (a *App) CombineShamirShares(shares []string, password string) (*KeyResult, error) {
+	if err := keystorefmt.ValidatePassword(password); err != nil {
+		return nil, err
+	}
+
+	key, err := combineShares(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.newKeystoreResult(key, password)
+}
+
+// VerifyShamirShares combines Shamir shares and returns the recovered address
+// without producing a keystore. Shares of a mnemonic or seed return the address
+// of its first account; passphrase is only used for mnemonic entropy shares.
+func (a *App) VerifyShamirShares(shares []string, passphrase string) (string, error) {
+	values, err := sharing.ParseShares(shares)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := sharing.CombineSecret(values)
+	if err != nil {
+		return "", err
+	}
+	key, err := secret.Key(passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	return key.Address.Hex(), nil
+}
+
+// combineShares combines hex encoded Shamir shares into a private key
+func combineShares(shares []string) (*keygen.Key, error) {
+	shareBytes, err := sharing.ParseShares(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	return sharing.CombineKey(shareBytes)
+}
+
+// CreateShareKeystore creates a keystore for a specific share of the given
+// share set with the key derivation profile of the app
+func (a *App) CreateShareKeystore(shareHex string, password string, index int, shareSet ShareSetResult) (*ShareKeystoreResult, error) {
+	share, err := newShare(shareHex, index, shareSet)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.newShareKeystoreResult(share, password)
+}
+
+// CreateCustodianShare encrypts a specific share of the given share set to
+// the secp256k1 (ECIES) or X25519 public key of its custodian instead of a
+// password, so the share keystore can be sent to its holder over email or a
+// shared drive
+func (a *App) CreateCustodianShare(shareHex string, recipientPublicKey string, index int, shareSet ShareSetResult) (*ShareKeystoreResult, error) {
+	recipient, err := keystorefmt.ParseRecipient(recipientPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	share, err := newShare(shareHex, index, shareSet)
+	if err != nil {
+		return nil, err
+	}
+
+	keystoreJSON, err := keystorefmt.EncryptShareTo(share, recipient)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode share: %v", err)
-	}
-
-	// Create a keystore for the share
-	// Note: This is a simplified keystore for the share
-	keystoreData := map[string]interface{}{
-		"version":    3,
-		"id":         generateUUID(),
-		"address":    address,
-		"shareIndex": index,
-		"crypto": map[string]interface{}{
-			"cipher":       "aes-128-ctr",
-			"ciphertext":   hex.EncodeToString(shareBytes), // Store the actual share
-			"cipherparams": map[string]interface{}{"iv": hex.EncodeToString([]byte("initialization_vector"))},
-			"kdf":          "scrypt",
-			"kdfparams": map[string]interface{}{
-				"dklen": 32,
-				"salt":  hex.EncodeToString([]byte("salt")),
-				"n":     262144,
-				"r":     8,
-				"p":     1,
-			},
-			"mac": hex.EncodeToString([]byte("mac")),
-		},
-	}
-
-	keystoreJSON, _ := json.MarshalIndent(keystoreData, "", "  ")
+		return nil, err
+	}
 
 	return &ShareKeystoreResult{
 		Keystore: string(keystoreJSON),
@@ -265,31 +716,435 @@ func (a *App) CreateShareKeystore(shareHex string, password string, index int, a
 	}, nil
 }
 
-// createKeystore creates a JSON keystore file using ethereum keystore package
-func createKeystore(privateKey *ecdsa.PrivateKey, password string) string {
-	// Create a new keystore
-	ks := keystore.NewKeyStore("", keystore.StandardScryptN, keystore.StandardScryptP)
+// UnwrapCustodianShare decrypts a share encrypted to a custodian with the
+// custodian's own keystore and re-encrypts it as a share keystore under
+// newPassword, which recovery accepts like any other share keystore
+func (a *App) UnwrapCustodianShare(shareJSON string, custodianKeystore string, custodianPassword string, newPassword string) (*ShareKeystoreResult, error) {
+	if err := keystorefmt.ValidatePassword(newPassword); err != nil {
+		return nil, err
+	}
 
-	// Import the private key
-	account, err := ks.ImportECDSA(privateKey, password)
+	custodianKey, err := keystorefmt.DecryptKey([]byte(custodianKeystore), custodianPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt custodian keystore: %w", err)
+	}
+	share, err := keystorefmt.DecryptShareWith([]byte(shareJSON), custodianKey.PrivateKey)
 	if err != nil {
-		return ""
+		return nil, err
 	}
 
-	// Export the account to get the keystore JSON
-	exported, err := ks.Export(account, password, password)
+	return a.newShareKeystoreResult(*share, newPassword)
+}
+
+// UnwrapX25519Share decrypts a share encrypted to a custodian's X25519 public
+// key with the custodian's hex private key and re-encrypts it as a share
+// keystore under newPassword
+func (a *App) UnwrapX25519Share(shareJSON string, privateKeyHex string, newPassword string) (*ShareKeystoreResult, error) {
+	if err := keystorefmt.ValidatePassword(newPassword); err != nil {
+		return nil, err
+	}
+
+	privateKey, err := keystorefmt.ParseX25519Key(privateKeyHex)
 	if err != nil {
-		return ""
+		return nil, err
+	}
+	share, err := keystorefmt.DecryptShareWithX25519([]byte(shareJSON), privateKey)
+	if err != nil {
+		return nil, err
 	}
 
-	return string(exported)
+	return a.newShareKeystoreResult(*share, newPassword)
 }
 
-// generateUUID generates a simple UUID-like string
-func generateUUID() string {
-	b := make([]byte, 16)
-	rand.Read(b)
-	return hex.EncodeToString(b)
+// newShareKeystoreResult encrypts a share under a password with the key
+// derivation profile of the app
+func (a *App) newShareKeystoreResult(share sharing.Share, password string) (*ShareKeystoreResult, error) {
+	keystoreJSON, err := keystorefmt.EncryptShare(share, password, a.kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShareKeystoreResult{
+		Keystore: string(keystoreJSON),
+		Index:    share.Index,
+		KDF:      newKDFResult(a.kdf),
+	}, nil
+}
+
+// newShare builds a share of the given share set from its frontend view
+func newShare(shareHex string, index int, shareSet ShareSetResult) (sharing.Share, error) {
+	// Decode share from hex
+	shareBytes, err := hex.DecodeString(shareHex)
+	if err != nil {
+		return sharing.Share{}, fmt.Errorf("failed to decode share: %v", err)
+	}
+
+	set, err := shareSet.toSetInfo()
+	if err != nil {
+		return sharing.Share{}, err
+	}
+	if index < 1 || index > set.Total {
+		return sharing.Share{}, fmt.Errorf("share index %d is outside 1-%d", index, set.Total)
+	}
+
+	// Shares of a group policy are numbered across the groups in order
+	group := 0
+	if set.Policy != nil {
+		group = set.Policy.GroupOf(index)
+	}
+
+	return sharing.Share{
+		Index: index,
+		Group: group,
+		Set:   set,
+		Value: shareBytes,
+	}, nil
+}
+
+// DecryptShareKeystore decrypts a share keystore and returns the share with its metadata
+func (a *App) DecryptShareKeystore(keystoreJSON string, password string) (*DecryptedShareResult, error) {
+	share, err := keystorefmt.DecryptShare([]byte(keystoreJSON), password)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DecryptedShareResult{
+		Share:      hex.EncodeToString(share.Value),
+		ShareIndex: share.Index,
+		ShareGroup: share.Group,
+		Address:    share.Set.Address.Hex(),
+	}
+	if share.Set.ID != "" {
+		shareSet := newShareSetResult(share.Set)
+		result.ShareSet = &shareSet
+	}
+
+	return result, nil
+}
+
+// VerifyShareKeystore decrypts a verifiable share keystore and checks the share
+// against the commitments of its set, without any other share
+func (a *App) VerifyShareKeystore(keystoreJSON string, password string) (*DecryptedShareResult, error) {
+	share, err := keystorefmt.DecryptShare([]byte(keystoreJSON), password)
+	if err != nil {
+		return nil, err
+	}
+	if err := sharing.VerifyShare(*share); err != nil {
+		return nil, fmt.Errorf("share %d: %w", share.Index, err)
+	}
+
+	shareSet := newShareSetResult(share.Set)
+	return &DecryptedShareResult{
+		Share:      hex.EncodeToString(share.Value),
+		ShareIndex: share.Index,
+		ShareGroup: share.Group,
+		Address:    share.Set.Address.Hex(),
+		ShareSet:   &shareSet,
+	}, nil
+}
+
+// RecoverFromShareKeystores decrypts share keystores, combines the shares and
+// returns the recovered key encrypted under newPassword
+func (a *App) RecoverFromShareKeystores(keystores []string, passwords []string, newPassword string) (*KeyResult, error) {
+	result, err := a.RecoverSecretFromShareKeystores(keystores, passwords, "", newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result.KeyResult, nil
+}
+
+// RecoverSecretFromShareKeystores decrypts share keystores and recovers the
+// split secret: a private key, mnemonic or seed. The key of the secret's first
+// account is encrypted under newPassword. passphrase is the BIP-39 passphrase
+// of mnemonic shares and is ignored for other kinds.
+func (a *App) RecoverSecretFromShareKeystores(keystores []string, passwords []string, passphrase string, newPassword string) (*RecoveredSecretResult, error) {
+	if err := keystorefmt.ValidatePassword(newPassword); err != nil {
+		return nil, err
+	}
+
+	shares, err := decryptShareKeystores(keystores, passwords)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, key, err := sharing.RecoverSecret(shares, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	keyResult, err := a.newKeystoreResult(key, newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RecoveredSecretResult{
+		Kind:      secret.Kind.String(),
+		KeyResult: *keyResult,
+	}
+	switch secret.Kind {
+	case sharing.KindMnemonicEntropy:
+		if result.Mnemonic, err = secret.Mnemonic(); err != nil {
+			return nil, err
+		}
+		result.Path = keygen.DefaultPath.String()
+	case sharing.KindSeed:
+		result.Seed = hex.EncodeToString(secret.Value)
+		result.Path = keygen.DefaultPath.String()
+	}
+
+	return result, nil
+}
+
+// ReshareShareKeystores decrypts share keystores and splits their secret again
+// into a new share set, for example to replace a custodian. The new shares have
+// a new set ID and cannot be combined with the old ones; the address does not
+// change. The secret is only held in memory; of the key only the address is
+// part of the result.
+func (a *App) ReshareShareKeystores(keystores []string, passwords []string, passphrase string, totalShares int, threshold int) (*ShamirResult, error) {
+	if err := sharing.ValidateSplit(totalShares, threshold); err != nil {
+		return nil, err
+	}
+
+	shares, err := decryptShareKeystores(keystores, passwords)
+	if err != nil {
+		return nil, err
+	}
+
+	reshared, err := sharing.Reshare(shares, passphrase, totalShares, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newShamirResult(reshared)
+	result.Address = reshared[0].Set.Address.Hex()
+	result.Keystore = shareModeKeystore
+	return result, nil
+}
+
+// ExtendShareKeystores decrypts share keystores of a split and issues count
+// additional shares of the same set, for example for a new custodian. The
+// existing shares stay valid; the result only holds the new shares, numbered
+// after the existing ones. Of the key only the address is part of the result.
+func (a *App) ExtendShareKeystores(keystores []string, passwords []string, passphrase string, count int) (*ShamirResult, error) {
+	shares, err := decryptShareKeystores(keystores, passwords)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := sharing.ExtendShares(shares, passphrase, count)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newShamirResult(added)
+	result.Address = added[0].Set.Address.Hex()
+	result.Keystore = shareModeKeystore
+	return result, nil
+}
+
+// decryptShareKeystores decrypts share keystores, each with its own password
+func decryptShareKeystores(keystores []string, passwords []string) ([]sharing.Share, error) {
+	if len(keystores) != len(passwords) {
+		return nil, fmt.Errorf("got %d share keystores but %d passwords", len(keystores), len(passwords))
+	}
+
+	shares := make([]sharing.Share, len(keystores))
+	for i := range keystores {
+		share, err := keystorefmt.DecryptShare([]byte(keystores[i]), passwords[i])
+		if err != nil {
+			return nil, fmt.Errorf("share keystore %d: %w", i+1, err)
+		}
+		shares[i] = *share
+	}
+
+	return shares, nil
+}
+
+// newKeyResult builds the frontend view of a key
+func newKeyResult(key *keygen.Key, keystore string) *KeyResult {
+	return &KeyResult{
+		Keystore:   keystore,
+		PublicKey:  key.PublicKeyHex(),
+		PrivateKey: key.PrivateKeyHex(),
+		Address:    key.Address.Hex(),
+	}
+}
+
+// newKeystoreResult builds the frontend view of a key encrypted into a
+// keystore with the key derivation profile of the app
+func (a *App) newKeystoreResult(key *keygen.Key, password string) (*KeyResult, error) {
+	keystore, err := createKeystore(key, password, a.kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newKeyResult(key, keystore)
+	result.KDF = newKDFResult(a.kdf)
+	return result, nil
+}
+
+// newKDFResult builds the frontend view of a key derivation profile
+func newKDFResult(kdf keystorefmt.KDF) *KDFResult {
+	result := &KDFResult{
+		Profile:     kdf.Profile,
+		Function:    kdf.Function,
+		Description: kdf.String(),
+		Iterations:  kdf.Iterations,
+	}
+	if kdf.Function == "scrypt" {
+		result.N, result.R, result.P = kdf.N, kdf.R, kdf.P
+	}
+	return result
+}
+
+// newShamirResult builds the frontend view of the shares of one split
+func newShamirResult(shares []sharing.Share) *ShamirResult {
+	result := &ShamirResult{
+		Shares:     make([]string, len(shares)),
+		ShareWords: make([]string, len(shares)),
+		Indexes:    make([]int, len(shares)),
+		ShareSet:   newShareSetResult(shares[0].Set),
+	}
+	for i, share := range shares {
+		result.Shares[i] = hex.EncodeToString(share.Value)
+		result.Indexes[i] = share.Index
+		if share.Group > 0 {
+			result.Groups = append(result.Groups, share.Group)
+		}
+		result.ShareWords[i] = strings.Join(sharing.EncodeWords(share.Value), " ")
+	}
+
+	return result
+}
+
+// newShareSetResult builds the frontend view of share set metadata
+func newShareSetResult(set sharing.SetInfo) ShareSetResult {
+	result := ShareSetResult{
+		SetID:     set.ID,
+		Kind:      set.SecretKind().String(),
+		Address:   set.Address.Hex(),
+		Threshold: set.Threshold,
+		Total:     set.Total,
+		CreatedAt: set.CreatedAt.Format(time.RFC3339),
+		Version:   set.Version,
+	}
+	for _, commitment := range set.Commitments {
+		result.Commitments = append(result.Commitments, hex.EncodeToString(commitment))
+	}
+	for _, x := range set.Coordinates {
+		result.Coordinates = append(result.Coordinates, int(x))
+	}
+	if set.Policy != nil {
+		result.Policy = &PolicyResult{
+			Description: set.Policy.String(),
+			Threshold:   set.Policy.Threshold,
+		}
+		for _, group := range set.Policy.Groups {
+			result.Policy.Groups = append(result.Policy.Groups,
+				PolicyGroup{Name: group.Name, Threshold: group.Threshold, Count: group.Total})
+		}
+	}
+
+	return result
+}
+
+// newPolicy builds a group policy from its frontend view. groupThreshold 0
+// requires all groups.
+func newPolicy(groupThreshold int, groups []PolicyGroup) *sharing.Policy {
+	policy := &sharing.Policy{Threshold: groupThreshold}
+	for _, group := range groups {
+		policy.Groups = append(policy.Groups, sharing.Group{Name: group.Name, Threshold: group.Threshold, Total: group.Count})
+	}
+	if policy.Threshold == 0 {
+		policy.Threshold = len(policy.Groups)
+	}
+	return policy
+}
+
+// toSetInfo validates share set metadata coming from the frontend
+func (r ShareSetResult) toSetInfo() (sharing.SetInfo, error) {
+	if r.SetID == "" {
+		return sharing.SetInfo{}, fmt.Errorf("share set ID is missing")
+	}
+	if !common.IsHexAddress(r.Address) {
+		return sharing.SetInfo{}, fmt.Errorf("invalid address %q", r.Address)
+	}
+	if err := sharing.ValidateSplit(r.Total, r.Threshold); err != nil {
+		return sharing.SetInfo{}, fmt.Errorf("invalid share set: %w", err)
+	}
+	createdAt, err := time.Parse(time.RFC3339, r.CreatedAt)
+	if err != nil {
+		return sharing.SetInfo{}, fmt.Errorf("invalid share set creation time: %v", err)
+	}
+	kind := sharing.KindPrivateKey
+	if r.Kind != "" {
+		if kind, err = sharing.ParseSecretKind(r.Kind); err != nil {
+			return sharing.SetInfo{}, err
+		}
+	}
+	var commitments [][]byte
+	for i, commitment := range r.Commitments {
+		point, err := hex.DecodeString(commitment)
+		if err != nil {
+			return sharing.SetInfo{}, fmt.Errorf("invalid share set commitment %d: %v", i, err)
+		}
+		commitments = append(commitments, point)
+	}
+	var coordinates []byte
+	for _, x := range r.Coordinates {
+		if x < 1 || x > 255 {
+			return sharing.SetInfo{}, fmt.Errorf("invalid share coordinate %d", x)
+		}
+		coordinates = append(coordinates, byte(x))
+	}
+	var policy *sharing.Policy
+	if r.Policy != nil {
+		policy = newPolicy(r.Policy.Threshold, r.Policy.Groups)
+		if err := policy.Validate(); err != nil {
+			return sharing.SetInfo{}, fmt.Errorf("invalid share set: %w", err)
+		}
+		if policy.TotalShares() != r.Total {
+			return sharing.SetInfo{}, fmt.Errorf("invalid share set: policy has %d shares, set has %d", policy.TotalShares(), r.Total)
+		}
+	}
+
+	return sharing.SetInfo{
+		ID:          r.SetID,
+		Kind:        kind,
+		Address:     common.HexToAddress(r.Address),
+		Threshold:   r.Threshold,
+		Total:       r.Total,
+		CreatedAt:   createdAt,
+		Version:     r.Version,
+		Commitments: commitments,
+		Coordinates: coordinates,
+		Policy:      policy,
+	}, nil
+}
+
+// createKeystore creates a JSON keystore file with the given key derivation
+// and decrypts it once, so a keystore that does not open with the password is
+// never handed out. Every keystore is held to the password policy; a missing
+// or weak password fails with a *keystorefmt.PasswordPolicyError.
+func createKeystore(key *keygen.Key, password string, kdf keystorefmt.KDF) (string, error) {
+	if err := keystorefmt.ValidatePassword(password); err != nil {
+		return "", err
+	}
+
+	exported, err := keystorefmt.EncryptKeyWith(key.PrivateKey, password, kdf)
+	if err != nil {
+		return "", err
+	}
+
+	decrypted, err := keystorefmt.DecryptKey(exported, password)
+	if err != nil {
+		return "", fmt.Errorf("failed to verify keystore: %w", err)
+	}
+	if decrypted.Address != key.Address {
+		return "", fmt.Errorf("failed to verify keystore: it decrypts to %s, expected %s", decrypted.Address.Hex(), key.Address.Hex())
+	}
+
+	return string(exported), nil
 }
 
 // Greet returns a greeting for the given name
@@ -0,0 +1,1330 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"key-generator/keygen"
+	"key-generator/keystorefmt"
+	"key-generator/sharing"
+	"key-generator/slip39"
+)
+
+// cliCommands maps subcommand names to their handlers
+var cliCommands = map[string]func(args []string) error{
+	"generate":        runGenerateCommand,
+	"derive":          runDeriveCommand,
+	"split":           runSplitCommand,
+	"combine":         runCombineCommand,
+	"reshare":         runReshareCommand,
+	"extend":          runExtendCommand,
+	"slip39-split":    runSLIP39SplitCommand,
+	"slip39-combine":  runSLIP39CombineCommand,
+	"verify-share":    runVerifyShareCommand,
+	"unwrap-share":    runUnwrapShareCommand,
+	"inspect":         runInspectCommand,
+	"change-password": runChangePasswordCommand,
+}
+
+const cliUsage = `Usage: key-generator <command> [flags] [args]
+
+Commands:
+  generate          Generate a new key and write it as a keystore
+  derive            List or export accounts of a BIP-39 mnemonic along an HD path
+  split             Generate a new key and write it as N share keystores or word lists,
+                    or as share keystores of groups with --policy
+  combine           Recover a keystore from share keystores or raw hex or word shares
+  reshare           Split the key of share keystores again into a new share set
+  extend            Add shares to an existing split for new custodians
+  slip39-split      Generate a new wallet as SLIP-39 mnemonic shares
+  slip39-combine    Recover a keystore from SLIP-39 mnemonic shares
+  verify-share      Check a verifiable share keystore against its commitments
+  unwrap-share      Re-encrypt a share sent to a custodian key under a password
+  inspect           Show keystore or share keystore metadata without decrypting
+  change-password   Re-encrypt a keystore under a new password
+
+Passwords are read from the terminal, or one per line from --password-fd.
+Run "key-generator <command> -h" for command flags.
+Without a command the desktop app is started.
+`
+
+// isCLIInvocation reports whether the arguments select a headless command
+func isCLIInvocation(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "help", "-h", "--help":
+		return true
+	}
+	_, ok := cliCommands[args[0]]
+	return ok
+}
+
+// runCLI runs a headless command and returns the process exit code
+func runCLI(args []string) int {
+	command, ok := cliCommands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, cliUsage)
+		if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			return 0
+		}
+		return 2
+	}
+
+	if err := command(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// passwordReader reads passwords from the terminal or from a file descriptor
+type passwordReader struct {
+	file  *os.File
+	lines *bufio.Reader
+}
+
+// newPasswordReader creates a password reader; fd < 0 selects the terminal.
+// The reader takes the descriptor over, so callers close it when done.
+func newPasswordReader(fd int) (*passwordReader, error) {
+	if fd >= 0 {
+		file := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+		if file == nil {
+			return nil, fmt.Errorf("invalid password file descriptor %d", fd)
+		}
+		return &passwordReader{file: file, lines: bufio.NewReader(file)}, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("stdin is not a terminal, use --password-fd to supply passwords")
+	}
+	return &passwordReader{}, nil
+}
+
+// Close closes the password file descriptor; the terminal is left open
+func (r *passwordReader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+// read reads a single password
+func (r *passwordReader) read(prompt string) (string, error) {
+	if r.lines != nil {
+		line, err := r.lines.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("failed to read password for %q: %v", prompt, err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	return string(password), nil
+}
+
+// readNew reads a new password, checks the password policy and asks for
+// confirmation when reading from the terminal
+func (r *passwordReader) readNew(prompt string) (string, error) {
+	password, err := r.read(prompt)
+	if err != nil {
+		return "", err
+	}
+	if err := keystorefmt.ValidatePassword(password); err != nil {
+		return "", err
+	}
+
+	if r.lines == nil {
+		confirm, err := r.read("Confirm " + strings.ToLower(prompt[:1]) + prompt[1:])
+		if err != nil {
+			return "", err
+		}
+		if confirm != password {
+			return "", fmt.Errorf("passwords do not match")
+		}
+	}
+
+	return password, nil
+}
+
+// newFlagSet creates a flag set for a subcommand with the shared flags
+func newFlagSet(name string, usage string) (*flag.FlagSet, *int, *bool) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: key-generator %s %s\n\nFlags:\n", name, usage)
+		flags.PrintDefaults()
+	}
+	passwordFD := flags.Int("password-fd", -1, "read passwords one per line from this file descriptor instead of the terminal")
+	jsonOutput := flags.Bool("json", false, "print machine-readable JSON output")
+	return flags, passwordFD, jsonOutput
+}
+
+// kdfFlag adds the --kdf flag of commands that write keystores
+func kdfFlag(flags *flag.FlagSet) *string {
+	return flags.String("kdf", "standard", "key derivation of written keystores: light, standard, paranoid or pbkdf2")
+}
+
+// newKDFApp creates the App of a command that writes keystores with the key
+// derivation profile of its --kdf flag
+func newKDFApp(profile string) (*App, error) {
+	app := NewApp()
+	if _, err := app.SetKDFProfile(profile); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// printResult prints a command result as JSON or as aligned text lines
+func printResult(jsonOutput bool, result interface{}, lines [][2]string) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	for _, line := range lines {
+		fmt.Printf("%-14s %s\n", line[0]+":", line[1])
+	}
+	return nil
+}
+
+// writeNewFile writes content to a file that must not exist yet
+func writeNewFile(path string, content string) error {
+	if content == "" {
+		return fmt.Errorf("refusing to write empty file %s", path)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return file.Close()
+}
+
+// keystoreFileResult describes a keystore written by the CLI
+type keystoreFileResult struct {
+	Address   string     `json:"address"`
+	PublicKey string     `json:"publicKey,omitempty"`
+	File      string     `json:"file"`
+	KDF       *KDFResult `json:"kdf,omitempty"`
+	Path      string     `json:"path,omitempty"`
+	Mnemonic  string     `json:"mnemonic,omitempty"`
+	Seed      string     `json:"seed,omitempty"`
+}
+
+// runGenerateCommand generates a new key, optionally from a BIP-39 mnemonic, and writes its keystore
+func runGenerateCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("generate", "[flags]")
+	outDir := flags.String("out-dir", ".", "directory to write the keystore to")
+	mnemonicWords := flags.Int("mnemonic", 0, "generate a BIP-39 mnemonic of 12 or 24 words and derive the key from it")
+	importMnemonic := flags.Bool("import-mnemonic", false, "read an existing BIP-39 mnemonic and derive the key from it")
+	usePassphrase := flags.Bool("passphrase", false, "read an optional BIP-39 passphrase for the mnemonic")
+	kdf := kdfFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *mnemonicWords != 0 && *importMnemonic {
+		return fmt.Errorf("--mnemonic and --import-mnemonic cannot be combined")
+	}
+	if *usePassphrase && *mnemonicWords == 0 && !*importMnemonic {
+		return fmt.Errorf("--passphrase requires --mnemonic or --import-mnemonic")
+	}
+	app, err := newKDFApp(*kdf)
+	if err != nil {
+		return err
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+
+	var mnemonic, passphrase string
+	if *importMnemonic {
+		if mnemonic, err = passwords.read("Mnemonic"); err != nil {
+			return err
+		}
+	}
+	if *usePassphrase {
+		if passphrase, err = passwords.read("BIP-39 passphrase"); err != nil {
+			return err
+		}
+	}
+
+	password, err := passwords.readNew("Keystore password")
+	if err != nil {
+		return err
+	}
+
+	var result *MnemonicResult
+	switch {
+	case *importMnemonic:
+		result, err = app.ImportMnemonic(mnemonic, passphrase, password)
+	case *mnemonicWords != 0:
+		result, err = app.GenerateMnemonicKey(password, *mnemonicWords, passphrase)
+	default:
+		var key *KeyResult
+		if key, err = app.GenerateKey(password); err == nil {
+			result = &MnemonicResult{KeyResult: *key}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(*outDir, strings.TrimPrefix(result.Address, "0x")+"_keystore.json")
+	if err := writeNewFile(file, result.Keystore); err != nil {
+		return err
+	}
+
+	lines := [][2]string{
+		{"Address", result.Address},
+		{"Public key", result.PublicKey},
+		{"Keystore", file},
+		{"KDF", result.KDF.Description},
+	}
+	if result.Mnemonic != "" {
+		lines = append(lines,
+			[2]string{"Path", result.Path},
+			[2]string{"Mnemonic", result.Mnemonic},
+		)
+	}
+
+	return printResult(*jsonOutput, keystoreFileResult{
+		Address:   result.Address,
+		PublicKey: result.PublicKey,
+		File:      file,
+		KDF:       result.KDF,
+		Path:      result.Path,
+		Mnemonic:  result.Mnemonic,
+	}, lines)
+}
+
+// derivedAccountResult describes an account listed or written by the derive command
+type derivedAccountResult struct {
+	Path    string `json:"path"`
+	Address string `json:"address"`
+	File    string `json:"file,omitempty"`
+}
+
+// runDeriveCommand derives a range of accounts from a BIP-39 mnemonic and
+// optionally writes their keystores
+func runDeriveCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("derive", "[flags]")
+	path := flags.String("path", keygen.DefaultPath.String(), "derivation path; its last component is replaced by the account index")
+	start := flags.Int("start", 0, "first account index")
+	count := flags.Int("count", 10, "number of accounts to derive")
+	usePassphrase := flags.Bool("passphrase", false, "read an optional BIP-39 passphrase for the mnemonic")
+	writeKeystores := flags.Bool("keystores", false, "write a keystore for every derived account")
+	outDir := flags.String("out-dir", ".", "directory to write the keystores to")
+	kdf := kdfFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	app, err := newKDFApp(*kdf)
+	if err != nil {
+		return err
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+
+	mnemonic, err := passwords.read("Mnemonic")
+	if err != nil {
+		return err
+	}
+	var passphrase, password string
+	if *usePassphrase {
+		if passphrase, err = passwords.read("BIP-39 passphrase"); err != nil {
+			return err
+		}
+	}
+	if *writeKeystores {
+		if password, err = passwords.readNew("Keystore password"); err != nil {
+			return err
+		}
+	}
+
+	accounts, err := app.DeriveMnemonicKeys(mnemonic, passphrase, *path, *start, *count, password)
+	if err != nil {
+		return err
+	}
+
+	results := make([]derivedAccountResult, len(accounts))
+	lines := make([][2]string, len(accounts))
+	for i, account := range accounts {
+		results[i] = derivedAccountResult{Path: account.Path, Address: account.Address}
+		lines[i] = [2]string{account.Path, account.Address}
+
+		if *writeKeystores {
+			results[i].File = filepath.Join(*outDir, strings.TrimPrefix(account.Address, "0x")+"_keystore.json")
+			if err := writeNewFile(results[i].File, account.Keystore); err != nil {
+				return err
+			}
+			lines[i][1] += "  " + results[i].File
+		}
+	}
+
+	return printResult(*jsonOutput, results, lines)
+}
+
+// splitResult describes the share keystores written by the split command
+type splitResult struct {
+	Address   string     `json:"address"`
+	PublicKey string     `json:"publicKey,omitempty"`
+	SetID     string     `json:"setId"`
+	Kind      string     `json:"kind"`
+	Shares    int        `json:"shares"`
+	Threshold int        `json:"threshold"`
+	Policy    string     `json:"policy,omitempty"`
+	Indexes   []int      `json:"indexes"`
+	Groups    []int      `json:"groups,omitempty"`
+	Files     []string   `json:"files"`
+	KDF       *KDFResult `json:"kdf,omitempty"`
+}
+
+// runSplitCommand generates a new key or mnemonic and writes it as encrypted share keystores
+// or as word lists
+func runSplitCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("split", "--shares N --threshold K [flags] | --policy GROUPS [flags]")
+	totalShares := flags.Int("shares", 0, "total number of shares to create")
+	threshold := flags.Int("threshold", 0, "number of shares required to recover the key")
+	outDir := flags.String("out-dir", ".", "directory to write the share keystores to")
+	mnemonicWords := flags.Int("mnemonic", 0, "generate a BIP-39 mnemonic of 12 or 24 words and split it instead of a private key")
+	importMnemonic := flags.Bool("import-mnemonic", false, "read an existing BIP-39 mnemonic and split it")
+	usePassphrase := flags.Bool("passphrase", false, "read an optional BIP-39 passphrase for the mnemonic")
+	secretKind := flags.String("secret", "mnemonic-entropy", "what to split of a mnemonic: mnemonic-entropy or seed")
+	verifiable := flags.Bool("verifiable", false, "publish commitments so every holder can verify their share alone (private keys only)")
+	words := flags.Bool("words", false, "write each share as an unencrypted checksummed word list for paper backups instead of a share keystore")
+	policyGroups := flags.String("policy", "", "split among groups written as NAME:T/N separated by commas, e.g. executives:2/3,security:1/4 (private keys only)")
+	groupThreshold := flags.Int("groups-needed", 0, "number of --policy groups required to recover the key (default all)")
+	importKey := flags.Bool("import-key", false, "read an existing hex private key and split it instead of generating one")
+	keystoreFile := flags.String("keystore", "", "split the key of an existing V3 keystore instead of generating one")
+	recipientsFile := flags.String("recipients", "", "file with one custodian secp256k1 or X25519 public key per share, one per line; shares are encrypted to them instead of passwords")
+	kdf := kdfFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	app, err := newKDFApp(*kdf)
+	if err != nil {
+		return err
+	}
+
+	var policy *sharing.Policy
+	if *policyGroups != "" {
+		if *totalShares != 0 || *threshold != 0 {
+			return fmt.Errorf("--policy replaces --shares and --threshold")
+		}
+		if *mnemonicWords != 0 || *importMnemonic || *verifiable || *words {
+			return fmt.Errorf("--policy only splits private keys into share keystores")
+		}
+		var err error
+		if policy, err = sharing.ParsePolicy(*policyGroups, *groupThreshold); err != nil {
+			return err
+		}
+		*totalShares = policy.TotalShares()
+	} else if err := sharing.ValidateSplit(*totalShares, *threshold); err != nil {
+		return err
+	}
+	var recipients []string
+	if *recipientsFile != "" {
+		if *words {
+			return fmt.Errorf("--recipients cannot be combined with --words")
+		}
+		var err error
+		if recipients, err = readRecipients(*recipientsFile, *totalShares); err != nil {
+			return err
+		}
+	}
+	if *mnemonicWords != 0 && *importMnemonic {
+		return fmt.Errorf("--mnemonic and --import-mnemonic cannot be combined")
+	}
+	if *usePassphrase && *mnemonicWords == 0 && !*importMnemonic {
+		return fmt.Errorf("--passphrase requires --mnemonic or --import-mnemonic")
+	}
+	if *verifiable && (*mnemonicWords != 0 || *importMnemonic) {
+		return fmt.Errorf("--verifiable only splits private keys")
+	}
+	if *importKey && (*mnemonicWords != 0 || *importMnemonic || *verifiable || policy != nil || *keystoreFile != "") {
+		return fmt.Errorf("--import-key cannot be combined with --mnemonic, --import-mnemonic, --verifiable, --policy or --keystore")
+	}
+	var keystoreJSON []byte
+	if *keystoreFile != "" {
+		if *mnemonicWords != 0 || *importMnemonic || *verifiable || policy != nil {
+			return fmt.Errorf("--keystore cannot be combined with --mnemonic, --import-mnemonic, --verifiable or --policy")
+		}
+		var err error
+		if keystoreJSON, err = os.ReadFile(*keystoreFile); err != nil {
+			return fmt.Errorf("failed to read %s: %v", *keystoreFile, err)
+		}
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+
+	var mnemonic, passphrase, keystorePassword, privateKey string
+	if *importKey {
+		if privateKey, err = passwords.read("Private key (hex)"); err != nil {
+			return err
+		}
+	}
+	if keystoreJSON != nil {
+		if keystorePassword, err = passwords.read("Keystore password"); err != nil {
+			return err
+		}
+	}
+	if *importMnemonic {
+		if mnemonic, err = passwords.read("Mnemonic"); err != nil {
+			return err
+		}
+	}
+	if *usePassphrase {
+		if passphrase, err = passwords.read("BIP-39 passphrase"); err != nil {
+			return err
+		}
+	}
+
+	// Read all share passwords before any key material is generated; word
+	// shares are written unencrypted and custodian shares need none
+	var sharePasswords []string
+	if !*words && recipients == nil {
+		sharePasswords = make([]string, *totalShares)
+		for i := range sharePasswords {
+			prompt := fmt.Sprintf("Password for share %d", i+1)
+			if policy != nil {
+				prompt = fmt.Sprintf("Password for share %d (%s)", i+1, policy.Groups[policy.GroupOf(i+1)-1].Name)
+			}
+			sharePasswords[i], err = passwords.readNew(prompt)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var result *ShamirResult
+	switch {
+	case *importKey:
+		result, err = app.SplitPrivateKey(privateKey, *totalShares, *threshold)
+	case keystoreJSON != nil:
+		result, err = app.SplitKeystore(string(keystoreJSON), keystorePassword, *totalShares, *threshold)
+	case policy != nil:
+		groups := make([]PolicyGroup, len(policy.Groups))
+		for i, group := range policy.Groups {
+			groups[i] = PolicyGroup{Name: group.Name, Threshold: group.Threshold, Count: group.Total}
+		}
+		result, err = app.GeneratePolicyShares(policy.Threshold, groups)
+	case *importMnemonic:
+		result, err = app.SplitMnemonic(mnemonic, passphrase, *secretKind, *totalShares, *threshold)
+	case *mnemonicWords != 0:
+		result, err = app.GenerateMnemonicShares(*mnemonicWords, passphrase, *secretKind, *totalShares, *threshold)
+	case *verifiable:
+		result, err = app.GenerateVerifiableShares(*totalShares, *threshold)
+	default:
+		result, err = app.GenerateShamirShares("", *totalShares, *threshold)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Make sure the shares recover the generated address before writing them;
+	// GeneratePolicyShares has already checked its shares group by group
+	if policy == nil {
+		recoveredAddress, err := app.VerifyShamirShares(result.Shares, passphrase)
+		if err != nil {
+			return err
+		}
+		if recoveredAddress != result.Address {
+			return fmt.Errorf("shares recover %s instead of %s", recoveredAddress, result.Address)
+		}
+	}
+
+	files, err := writeShareFiles(app, result, sharePasswords, recipients, *outDir, *words)
+	if err != nil {
+		return err
+	}
+
+	var shareKDF *KDFResult
+	if !*words && recipients == nil {
+		shareKDF = newKDFResult(app.kdf)
+	}
+	return printSplitResult(*jsonOutput, result, files, shareKDF)
+}
+
+// writeShareFiles writes the shares of a split to outDir, as share keystores
+// encrypted under sharePasswords or to the custodian public keys recipients,
+// or, with words, as plain word lists
+func writeShareFiles(app *App, result *ShamirResult, sharePasswords []string, recipients []string, outDir string, words bool) ([]string, error) {
+	files := make([]string, len(result.Shares))
+	for i, share := range result.Shares {
+		if words {
+			files[i] = filepath.Join(outDir, fmt.Sprintf("%s_sharewords_%d.txt", strings.TrimPrefix(result.Address, "0x"), result.Indexes[i]))
+			if err := writeNewFile(files[i], result.ShareWords[i]+"\n"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var shareKeystore *ShareKeystoreResult
+		var err error
+		if recipients != nil {
+			shareKeystore, err = app.CreateCustodianShare(share, recipients[i], result.Indexes[i], result.ShareSet)
+		} else {
+			shareKeystore, err = app.CreateShareKeystore(share, sharePasswords[i], result.Indexes[i], result.ShareSet)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		files[i] = filepath.Join(outDir, fmt.Sprintf("%s_sharekey_%d.json", strings.TrimPrefix(result.Address, "0x"), result.Indexes[i]))
+		if err := writeNewFile(files[i], shareKeystore.Keystore); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// printSplitResult prints the share set of a split, the files its shares were
+// written to and the key derivation of password share keystores, nil for
+// share words and custodian shares
+func printSplitResult(jsonOutput bool, result *ShamirResult, files []string, kdf *KDFResult) error {
+	set := result.ShareSet
+	lines := [][2]string{{"Address", result.Address}}
+	if result.PublicKey != "" {
+		lines = append(lines, [2]string{"Public key", result.PublicKey})
+	}
+	lines = append(lines,
+		[2]string{"Share set", set.SetID},
+		[2]string{"Secret", set.Kind},
+		[2]string{"Threshold", fmt.Sprintf("%d of %d", set.Threshold, set.Total)},
+	)
+	policy := ""
+	if set.Policy != nil {
+		policy = set.Policy.Description
+		lines = append(lines, [2]string{"Policy", policy})
+	}
+	if kdf != nil {
+		lines = append(lines, [2]string{"KDF", kdf.Description})
+	}
+	for i, file := range files {
+		label := fmt.Sprintf("Share %d", result.Indexes[i])
+		if set.Policy != nil {
+			label += " (" + set.Policy.Groups[result.Groups[i]-1].Name + ")"
+		}
+		lines = append(lines, [2]string{label, file})
+	}
+
+	return printResult(jsonOutput, splitResult{
+		Address:   result.Address,
+		PublicKey: result.PublicKey,
+		SetID:     set.SetID,
+		Kind:      set.Kind,
+		Shares:    set.Total,
+		Indexes:   result.Indexes,
+		Threshold: set.Threshold,
+		Policy:    policy,
+		Groups:    result.Groups,
+		Files:     files,
+		KDF:       kdf,
+	}, lines)
+}
+
+// readRecipients reads one custodian public key per line for each of count shares
+func readRecipients(path string, count int) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var recipients []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if _, err := keystorefmt.ParseRecipient(line); err != nil {
+				return nil, fmt.Errorf("%s: recipient %d: %w", path, len(recipients)+1, err)
+			}
+			recipients = append(recipients, line)
+		}
+	}
+	if len(recipients) != count {
+		return nil, fmt.Errorf("%s has %d recipients for %d shares", path, len(recipients), count)
+	}
+
+	return recipients, nil
+}
+
+// runCombineCommand recovers a keystore from share keystores or raw hex or word shares
+func runCombineCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("combine", "[flags] SHARE_FILE...")
+	raw := flags.Bool("raw", false, "share files contain hex or word shares, one per line, instead of share keystores")
+	usePassphrase := flags.Bool("passphrase", false, "read the BIP-39 passphrase of mnemonic shares")
+	out := flags.String("out", "", "file to write the recovered keystore to (default {address}_keystore.json)")
+	kdf := kdfFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no share files given")
+	}
+	if *raw && *usePassphrase {
+		return fmt.Errorf("--passphrase cannot be used with --raw")
+	}
+	app, err := newKDFApp(*kdf)
+	if err != nil {
+		return err
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+
+	var result *RecoveredSecretResult
+	if *raw {
+		var shares []string
+		for _, path := range flags.Args() {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", path, err)
+			}
+			for _, line := range strings.Split(string(content), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					shares = append(shares, line)
+				}
+			}
+		}
+
+		password, err := passwords.readNew("Recovered keystore password")
+		if err != nil {
+			return err
+		}
+		key, err := app.CombineShamirShares(shares, password)
+		if err != nil {
+			return err
+		}
+		result = &RecoveredSecretResult{Kind: sharing.KindPrivateKey.String(), KeyResult: *key}
+	} else {
+		keystores, sharePasswords, err := readShareKeystores(flags.Args(), passwords)
+		if err != nil {
+			return err
+		}
+
+		var passphrase string
+		if *usePassphrase {
+			if passphrase, err = passwords.read("BIP-39 passphrase"); err != nil {
+				return err
+			}
+		}
+
+		password, err := passwords.readNew("Recovered keystore password")
+		if err != nil {
+			return err
+		}
+		if result, err = app.RecoverSecretFromShareKeystores(keystores, sharePasswords, passphrase, password); err != nil {
+			return err
+		}
+	}
+
+	file := *out
+	if file == "" {
+		file = strings.TrimPrefix(result.Address, "0x") + "_keystore.json"
+	}
+	if err := writeNewFile(file, result.Keystore); err != nil {
+		return err
+	}
+
+	lines := [][2]string{
+		{"Address", result.Address},
+		{"Public key", result.PublicKey},
+		{"Keystore", file},
+		{"KDF", result.KDF.Description},
+	}
+	if result.Path != "" {
+		lines = append(lines, [2]string{"Path", result.Path})
+	}
+	if result.Mnemonic != "" {
+		lines = append(lines, [2]string{"Mnemonic", result.Mnemonic})
+	}
+	if result.Seed != "" {
+		lines = append(lines, [2]string{"Seed", result.Seed})
+	}
+
+	return printResult(*jsonOutput, keystoreFileResult{
+		Address:   result.Address,
+		PublicKey: result.PublicKey,
+		File:      file,
+		KDF:       result.KDF,
+		Path:      result.Path,
+		Mnemonic:  result.Mnemonic,
+		Seed:      result.Seed,
+	}, lines)
+}
+
+// runReshareCommand splits the secret of share keystores again into a new
+// share set, without writing the secret itself anywhere
+func runReshareCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("reshare", "--shares N --threshold K --out-dir DIR [flags] SHARE_FILE...")
+	totalShares := flags.Int("shares", 0, "total number of new shares to create")
+	threshold := flags.Int("threshold", 0, "number of new shares required to recover the key")
+	outDir := flags.String("out-dir", "", "directory to write the new share keystores to, apart from the old ones")
+	usePassphrase := flags.Bool("passphrase", false, "read the BIP-39 passphrase of mnemonic shares")
+	kdf := kdfFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || *outDir == "" {
+		flags.Usage()
+		return fmt.Errorf("expected share files and --out-dir")
+	}
+	if err := sharing.ValidateSplit(*totalShares, *threshold); err != nil {
+		return err
+	}
+	app, err := newKDFApp(*kdf)
+	if err != nil {
+		return err
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+
+	keystores, oldPasswords, err := readShareKeystores(flags.Args(), passwords)
+	if err != nil {
+		return err
+	}
+
+	var passphrase string
+	if *usePassphrase {
+		if passphrase, err = passwords.read("BIP-39 passphrase"); err != nil {
+			return err
+		}
+	}
+
+	sharePasswords := make([]string, *totalShares)
+	for i := range sharePasswords {
+		sharePasswords[i], err = passwords.readNew(fmt.Sprintf("Password for new share %d", i+1))
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := app.ReshareShareKeystores(keystores, oldPasswords, passphrase, *totalShares, *threshold)
+	if err != nil {
+		return err
+	}
+
+	files, err := writeShareFiles(app, result, sharePasswords, nil, *outDir, false)
+	if err != nil {
+		return err
+	}
+
+	return printSplitResult(*jsonOutput, result, files, newKDFResult(app.kdf))
+}
+
+// runExtendCommand issues additional shares of an existing split; the shares
+// already handed out stay valid
+func runExtendCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("extend", "--count N --out-dir DIR [flags] SHARE_FILE...")
+	count := flags.Int("count", 1, "number of shares to add")
+	outDir := flags.String("out-dir", "", "directory to write the new share keystores to")
+	usePassphrase := flags.Bool("passphrase", false, "read the BIP-39 passphrase of mnemonic shares")
+	kdf := kdfFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || *outDir == "" {
+		flags.Usage()
+		return fmt.Errorf("expected share files and --out-dir")
+	}
+	if *count < 1 {
+		return fmt.Errorf("--count must be at least 1")
+	}
+	app, err := newKDFApp(*kdf)
+	if err != nil {
+		return err
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+
+	keystores, sharePasswords, err := readShareKeystores(flags.Args(), passwords)
+	if err != nil {
+		return err
+	}
+
+	var passphrase string
+	if *usePassphrase {
+		if passphrase, err = passwords.read("BIP-39 passphrase"); err != nil {
+			return err
+		}
+	}
+
+	newPasswords := make([]string, *count)
+	for i := range newPasswords {
+		newPasswords[i], err = passwords.readNew(fmt.Sprintf("Password for new share %d", i+1))
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := app.ExtendShareKeystores(keystores, sharePasswords, passphrase, *count)
+	if err != nil {
+		return err
+	}
+
+	files, err := writeShareFiles(app, result, newPasswords, nil, *outDir, false)
+	if err != nil {
+		return err
+	}
+
+	return printSplitResult(*jsonOutput, result, files, newKDFResult(app.kdf))
+}
+
+// readShareKeystores reads share keystore files and the password of each
+func readShareKeystores(paths []string, passwords *passwordReader) ([]string, []string, error) {
+	keystores := make([]string, len(paths))
+	sharePasswords := make([]string, len(paths))
+	for i, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		keystores[i] = string(content)
+
+		sharePasswords[i], err = passwords.read(fmt.Sprintf("Password for %s", filepath.Base(path)))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return keystores, sharePasswords, nil
+}
+
+// slip39SplitResult describes the SLIP-39 shares printed by the slip39-split command
+type slip39SplitResult struct {
+	Address        string     `json:"address"`
+	Path           string     `json:"path"`
+	GroupThreshold int        `json:"groupThreshold"`
+	Groups         [][]string `json:"groups"`
+}
+
+// runSLIP39SplitCommand generates a master secret and prints it as SLIP-39 mnemonic shares
+func runSLIP39SplitCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("slip39-split", "--groups T/N[,T/N...] [flags]")
+	groupSpec := flags.String("groups", "", "member threshold and count of each group, for example 2/3 or 1/1,2/3,3/5")
+	groupThreshold := flags.Int("group-threshold", 1, "number of groups required to recover the wallet")
+	strength := flags.Int("strength", 128, "master secret length in bits, 128 or 256")
+	usePassphrase := flags.Bool("passphrase", false, "read a passphrase that is also required for recovery")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *groupSpec == "" {
+		flags.Usage()
+		return fmt.Errorf("--groups is required")
+	}
+
+	specs, err := slip39.ParseGroups(*groupSpec)
+	if err != nil {
+		return err
+	}
+	groups := make([]SLIP39Group, len(specs))
+	for i, spec := range specs {
+		groups[i] = SLIP39Group{Threshold: spec.Threshold, Count: spec.Count}
+	}
+
+	var passphrase string
+	if *usePassphrase {
+		passwords, err := newPasswordReader(*passwordFD)
+		if err != nil {
+			return err
+		}
+		defer passwords.Close()
+		if passphrase, err = passwords.read("SLIP-39 passphrase"); err != nil {
+			return err
+		}
+	}
+
+	result, err := NewApp().GenerateSLIP39Shares(*strength, passphrase, *groupThreshold, groups)
+	if err != nil {
+		return err
+	}
+
+	lines := [][2]string{
+		{"Address", result.Address},
+		{"Path", result.Path},
+		{"Groups", fmt.Sprintf("%d of %d", result.GroupThreshold, len(result.Groups))},
+	}
+	for i, group := range result.Groups {
+		for j, mnemonic := range group {
+			lines = append(lines, [2]string{fmt.Sprintf("Share %d.%d", i+1, j+1), mnemonic})
+		}
+	}
+
+	return printResult(*jsonOutput, slip39SplitResult{
+		Address:        result.Address,
+		Path:           result.Path,
+		GroupThreshold: result.GroupThreshold,
+		Groups:         result.Groups,
+	}, lines)
+}
+
+// runSLIP39CombineCommand recovers a keystore from files of SLIP-39 mnemonic shares
+func runSLIP39CombineCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("slip39-combine", "[flags] SHARES_FILE...")
+	out := flags.String("out", "", "file to write the recovered keystore to (default {address}_keystore.json)")
+	usePassphrase := flags.Bool("passphrase", false, "read the SLIP-39 passphrase")
+	kdf := kdfFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no share files given")
+	}
+	app, err := newKDFApp(*kdf)
+	if err != nil {
+		return err
+	}
+
+	// Share files hold one mnemonic share per line
+	var mnemonics []string
+	for _, path := range flags.Args() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				mnemonics = append(mnemonics, line)
+			}
+		}
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+	var passphrase string
+	if *usePassphrase {
+		if passphrase, err = passwords.read("SLIP-39 passphrase"); err != nil {
+			return err
+		}
+	}
+	password, err := passwords.readNew("Recovered keystore password")
+	if err != nil {
+		return err
+	}
+
+	result, err := app.RecoverSLIP39Shares(mnemonics, passphrase, password)
+	if err != nil {
+		return err
+	}
+
+	file := *out
+	if file == "" {
+		file = strings.TrimPrefix(result.Address, "0x") + "_keystore.json"
+	}
+	if err := writeNewFile(file, result.Keystore); err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, keystoreFileResult{
+		Address:   result.Address,
+		PublicKey: result.PublicKey,
+		File:      file,
+		KDF:       result.KDF,
+		Path:      result.Path,
+		Seed:      result.Seed,
+	}, [][2]string{
+		{"Address", result.Address},
+		{"Public key", result.PublicKey},
+		{"Keystore", file},
+		{"KDF", result.KDF.Description},
+		{"Path", result.Path},
+		{"Seed", result.Seed},
+	})
+}
+
+// runInspectCommand prints keystore metadata without asking for a password
+func runInspectCommand(args []string) error {
+	flags, _, jsonOutput := newFlagSet("inspect", "[flags] FILE")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one file")
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", flags.Arg(0), err)
+	}
+
+	result, err := keystorefmt.Inspect(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+
+	lines := [][2]string{
+		{"Type", result.Type},
+		{"Address", result.Address},
+		{"ID", result.ID},
+		{"Version", fmt.Sprint(result.Version)},
+	}
+	if result.Type == "share" {
+		lines = append(lines, [2]string{"Share index", fmt.Sprint(result.ShareIndex)})
+	}
+	if result.Recipient != "" {
+		lines = append(lines, [2]string{"Recipient", result.Recipient})
+	}
+	if set := result.ShareSet; set != nil && set.Policy != nil && result.ShareGroup >= 1 && result.ShareGroup <= len(set.Policy.Groups) {
+		lines = append(lines, [2]string{"Share group", set.Policy.Groups[result.ShareGroup-1].Name})
+	}
+	if set := result.ShareSet; set != nil {
+		lines = append(lines,
+			[2]string{"Share set", set.ID},
+			[2]string{"Secret", sharedSecretKind(set)},
+			[2]string{"Threshold", fmt.Sprintf("%d of %d", set.Threshold, set.Total)},
+			[2]string{"Created", set.CreatedAt.Format(time.RFC3339)},
+			[2]string{"Share format", fmt.Sprint(set.Version)},
+		)
+		if len(set.Commitments) > 0 {
+			lines = append(lines, [2]string{"Verifiable", fmt.Sprintf("yes, %d commitments", len(set.Commitments))})
+		}
+		if set.Policy != nil {
+			lines = append(lines, [2]string{"Policy", set.Policy.Description})
+		}
+	}
+	lines = append(lines,
+		[2]string{"Cipher", result.Cipher},
+		[2]string{"KDF", result.KDF},
+	)
+
+	return printResult(*jsonOutput, result, lines)
+}
+
+// shareFileResult describes a share keystore written by the CLI
+type shareFileResult struct {
+	Index int        `json:"index"`
+	File  string     `json:"file"`
+	KDF   *KDFResult `json:"kdf,omitempty"`
+}
+
+// runUnwrapShareCommand decrypts a share encrypted to a custodian with the
+// custodian's keystore and writes it as a password share keystore for combine
+func runUnwrapShareCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("unwrap-share", "(--key KEYSTORE_FILE | --x25519-key KEY_FILE) --out FILE [flags] SHARE_FILE")
+	keyFile := flags.String("key", "", "keystore of the secp256k1 custodian key the share is encrypted to")
+	x25519File := flags.String("x25519-key", "", "file with the hex X25519 private key the share is encrypted to")
+	out := flags.String("out", "", "file to write the password share keystore to")
+	kdf := kdfFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || (*keyFile == "") == (*x25519File == "") || *out == "" {
+		flags.Usage()
+		return fmt.Errorf("expected one share file, --key or --x25519-key, and --out")
+	}
+	app, err := newKDFApp(*kdf)
+	if err != nil {
+		return err
+	}
+
+	shareJSON, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", flags.Arg(0), err)
+	}
+	custodianFile := *keyFile
+	if custodianFile == "" {
+		custodianFile = *x25519File
+	}
+	custodianKey, err := os.ReadFile(custodianFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", custodianFile, err)
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+	var custodianPassword string
+	if *keyFile != "" {
+		if custodianPassword, err = passwords.read("Custodian keystore password"); err != nil {
+			return err
+		}
+	}
+	newPassword, err := passwords.readNew("Share password")
+	if err != nil {
+		return err
+	}
+
+	var result *ShareKeystoreResult
+	if *keyFile != "" {
+		result, err = app.UnwrapCustodianShare(string(shareJSON), string(custodianKey), custodianPassword, newPassword)
+	} else {
+		result, err = app.UnwrapX25519Share(string(shareJSON), string(custodianKey), newPassword)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+	if err := writeNewFile(*out, result.Keystore); err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, shareFileResult{
+		Index: result.Index,
+		File:  *out,
+		KDF:   result.KDF,
+	}, [][2]string{
+		{"Share index", fmt.Sprint(result.Index)},
+		{"Share keystore", *out},
+		{"KDF", result.KDF.Description},
+	})
+}
+
+// runVerifyShareCommand checks one verifiable share keystore against the
+// commitments of its share set, without any other share
+func runVerifyShareCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("verify-share", "[flags] SHARE_FILE")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected exactly one share file")
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", flags.Arg(0), err)
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+	password, err := passwords.read("Share password")
+	if err != nil {
+		return err
+	}
+
+	result, err := NewApp().VerifyShareKeystore(string(content), password)
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+
+	return printResult(*jsonOutput, result, [][2]string{
+		{"Address", result.Address},
+		{"Share index", fmt.Sprint(result.ShareIndex)},
+		{"Share set", result.ShareSet.SetID},
+		{"Threshold", fmt.Sprintf("%d of %d", result.ShareSet.Threshold, result.ShareSet.Total)},
+		{"Verified", "share matches the commitments"},
+	})
+}
+
+// sharedSecretKind returns the secret kind recorded in share set metadata;
+// metadata written before secret kinds existed describes a private key
+func sharedSecretKind(set *keystorefmt.ShareSetJSON) string {
+	if set.Kind == "" {
+		return sharing.KindPrivateKey.String()
+	}
+	return set.Kind
+}
+
+// runChangePasswordCommand re-encrypts a keystore under a new password
+func runChangePasswordCommand(args []string) error {
+	flags, passwordFD, jsonOutput := newFlagSet("change-password", "--out FILE [flags] KEYSTORE_FILE")
+	out := flags.String("out", "", "file to write the re-encrypted keystore to")
+	kdf := flags.String("kdf", "keep", "key derivation of the new keystore: keep, light, standard, paranoid or pbkdf2")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *out == "" {
+		flags.Usage()
+		return fmt.Errorf("expected one keystore file and --out")
+	}
+	if *kdf != "keep" {
+		if _, err := keystorefmt.ParseKDF(*kdf); err != nil {
+			return err
+		}
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", flags.Arg(0), err)
+	}
+
+	passwords, err := newPasswordReader(*passwordFD)
+	if err != nil {
+		return err
+	}
+	defer passwords.Close()
+	oldPassword, err := passwords.read("Current password")
+	if err != nil {
+		return err
+	}
+
+	newPassword, err := passwords.readNew("New password")
+	if err != nil {
+		return err
+	}
+
+	result, err := NewApp().ChangeKeystorePassword(string(content), oldPassword, newPassword, *kdf)
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+	if err := writeNewFile(*out, result.Keystore); err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, keystoreFileResult{
+		Address: result.Address,
+		File:    *out,
+		KDF:     result.KDF,
+	}, [][2]string{
+		{"Address", result.Address},
+		{"Keystore", *out},
+		{"KDF", result.KDF.Description},
+	})
+}
@@ -0,0 +1,810 @@
+//go:build unix
+
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+
+	"key-generator/keystorefmt"
+)
+
+// passwordPipe returns the read end of a pipe that yields the given passwords
+// one per line. The write end is closed here; the read end is handed to the
+// command on --password-fd, which closes it like any other caller's
+// descriptor. It is a raw descriptor rather than an *os.File, so nothing else
+// closes the number again after it has been reused.
+func passwordPipe(t *testing.T, passwords ...string) string {
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatal("Failed to create pipe:", err)
+	}
+	w := os.NewFile(uintptr(fds[1]), "passwords")
+	defer w.Close()
+
+	if _, err := w.WriteString(strings.Join(passwords, "\n") + "\n"); err != nil {
+		t.Fatal("Failed to write passwords:", err)
+	}
+
+	return fmt.Sprint(fds[0])
+}
+
+// cliCase is one run of a CLI command. Passwords are supplied on
+// --password-fd in order; check inspects the captured standard output.
+type cliCase struct {
+	name      string
+	args      []string
+	passwords []string
+	wantErr   bool
+	check     func(t *testing.T, stdout string)
+}
+
+// runCLICases runs the cases of one command in order, so later cases can use
+// the files written by earlier ones
+func runCLICases(t *testing.T, command func(args []string) error, cases []cliCase) {
+	t.Helper()
+	for _, tc := range cases {
+		fmt.Println("-", tc.name)
+		args := tc.args
+		if tc.passwords != nil {
+			args = append([]string{"--password-fd", passwordPipe(t, tc.passwords...)}, args...)
+		}
+
+		stdout, err := captureStdout(t, func() error { return command(args) })
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("❌ %s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("❌ %s: %v", tc.name, err)
+			continue
+		}
+		if tc.check != nil {
+			tc.check(t, stdout)
+		}
+	}
+}
+
+// captureStdout returns what run prints to standard output
+func captureStdout(t *testing.T, run func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal("Failed to create pipe:", err)
+	}
+	output := make(chan string)
+	go func() {
+		content, _ := io.ReadAll(r)
+		r.Close()
+		output <- string(content)
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	err = run()
+	os.Stdout = stdout
+	w.Close()
+
+	return <-output, err
+}
+
+// decodeJSON decodes the --json output of a command
+func decodeJSON(t *testing.T, stdout string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(stdout), v); err != nil {
+		t.Fatalf("❌ Invalid JSON output %q: %v", stdout, err)
+	}
+}
+
+// writeTestKeystore writes a keystore with the light profile and returns its
+// path and address
+func writeTestKeystore(t *testing.T, dir string, password string) (string, string) {
+	app := NewApp()
+	if _, err := app.SetKDFProfile("light"); err != nil {
+		t.Fatal("Failed to set profile:", err)
+	}
+	result, err := app.GenerateKey(password)
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	path := filepath.Join(dir, strings.TrimPrefix(result.Address, "0x")+"_keystore.json")
+	if err := writeNewFile(path, result.Keystore); err != nil {
+		t.Fatal(err)
+	}
+	return path, result.Address
+}
+
+func TestCLISplitAndCombine(t *testing.T) {
+	fmt.Println("=== CLI Split And Combine Test ===")
+
+	dir := t.TempDir()
+
+	// 1. Split a new key into share keystores
+	fmt.Println("1. Running split...")
+	fd := passwordPipe(t, "Share1!pass", "Share2!pass", "Share3!pass")
+	if err := runSplitCommand([]string{"--shares", "3", "--threshold", "2", "--password-fd", fd, "--out-dir", dir}); err != nil {
+		t.Fatal("Failed to split:", err)
+	}
+
+	shareFiles, err := filepath.Glob(filepath.Join(dir, "*_sharekey_*.json"))
+	if err != nil || len(shareFiles) != 3 {
+		t.Fatalf("Expected 3 share files, got %d (%v)", len(shareFiles), err)
+	}
+
+	// 2. Combine two share keystores from inside the share directory
+	fmt.Println("2. Running combine...")
+	out := filepath.Join(dir, "recovered.json")
+	fd = passwordPipe(t, "Share1!pass", "Share3!pass", "Recovered1!pass")
+	if err := runCombineCommand([]string{"--password-fd", fd, "--out", out, shareFiles[0], shareFiles[2]}); err != nil {
+		t.Fatal("Failed to combine:", err)
+	}
+
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal("Failed to read recovered keystore:", err)
+	}
+	key, err := keystore.DecryptKey(content, "Recovered1!pass")
+	if err != nil {
+		t.Fatal("Failed to decrypt recovered keystore:", err)
+	}
+
+	address := strings.TrimPrefix(key.Address.Hex(), "0x")
+	if !strings.HasPrefix(filepath.Base(shareFiles[0]), address) {
+		t.Errorf("❌ Recovered address %s does not match share file %s", address, shareFiles[0])
+	}
+
+	// 3. Existing files must not be overwritten
+	fmt.Println("3. Testing existing output file...")
+	fd = passwordPipe(t, "Share1!pass", "Share3!pass", "Recovered1!pass")
+	if err := runCombineCommand([]string{"--password-fd", fd, "--out", out, shareFiles[0], shareFiles[2]}); err == nil {
+		t.Error("❌ Existing keystore was overwritten!")
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCLIWeakPasswordRejected(t *testing.T) {
+	fd := passwordPipe(t, "weak")
+	if err := runGenerateCommand([]string{"--password-fd", fd, "--out-dir", t.TempDir()}); err == nil {
+		t.Error("❌ Weak password was accepted!")
+	}
+}
+
+func TestCLIInspect(t *testing.T) {
+	fmt.Println("=== CLI Inspect Test ===")
+
+	dir := t.TempDir()
+	keystoreFile, address := writeTestKeystore(t, dir, "Inspect1!pass")
+	fd := passwordPipe(t, "Share1!pass", "Share2!pass")
+	if err := runSplitCommand([]string{"--shares", "2", "--threshold", "2", "--password-fd", fd, "--out-dir", dir}); err != nil {
+		t.Fatal("Failed to split:", err)
+	}
+	shareFiles, err := filepath.Glob(filepath.Join(dir, "*_sharekey_1.json"))
+	if err != nil || len(shareFiles) != 1 {
+		t.Fatalf("Expected 1 share file, got %d (%v)", len(shareFiles), err)
+	}
+	notKeystore := filepath.Join(dir, "notes.json")
+	if err := os.WriteFile(notKeystore, []byte(`{"note":"not a keystore"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	runCLICases(t, runInspectCommand, []cliCase{
+		{
+			name: "keystore as text",
+			args: []string{keystoreFile},
+			check: func(t *testing.T, stdout string) {
+				if !strings.Contains(stdout, "keystore") || !strings.Contains(stdout, address) {
+					t.Errorf("❌ Unexpected output %q", stdout)
+				}
+			},
+		},
+		{
+			name: "keystore as JSON",
+			args: []string{"--json", keystoreFile},
+			check: func(t *testing.T, stdout string) {
+				var info keystorefmt.Info
+				decodeJSON(t, stdout, &info)
+				if info.Type != "keystore" || info.Address != address || info.KDF != "scrypt" {
+					t.Errorf("❌ Unexpected info %+v", info)
+				}
+			},
+		},
+		{
+			name: "share keystore as JSON",
+			args: []string{"--json", shareFiles[0]},
+			check: func(t *testing.T, stdout string) {
+				var info keystorefmt.Info
+				decodeJSON(t, stdout, &info)
+				if info.Type != "share" || info.ShareIndex != 1 || info.ShareSet == nil || info.ShareSet.Threshold != 2 {
+					t.Errorf("❌ Unexpected share info %+v", info)
+				}
+			},
+		},
+		{name: "not a keystore", args: []string{notKeystore}, wantErr: true},
+		{name: "missing file", args: []string{filepath.Join(dir, "missing.json")}, wantErr: true},
+		{name: "two files", args: []string{keystoreFile, shareFiles[0]}, wantErr: true},
+	})
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCLIChangePassword(t *testing.T) {
+	fmt.Println("=== CLI Change Password Test ===")
+
+	dir := t.TempDir()
+	keystoreFile, address := writeTestKeystore(t, dir, "Current1!pass")
+	out := filepath.Join(dir, "rotated.json")
+	pbkdf2Out := filepath.Join(dir, "pbkdf2.json")
+
+	// checkKeystore checks that a written keystore opens with the password
+	checkKeystore := func(t *testing.T, path string, password string, function string) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal("Failed to read keystore:", err)
+		}
+		key, err := keystore.DecryptKey(content, password)
+		if err != nil || key.Address.Hex() != address {
+			t.Fatalf("❌ %s does not open with the new password: %v", path, err)
+		}
+		if info, err := keystorefmt.Inspect(content); err != nil || info.KDF != function {
+			t.Errorf("❌ %s uses %v, expected %s", path, info, function)
+		}
+	}
+
+	runCLICases(t, runChangePasswordCommand, []cliCase{
+		{
+			// The current password is read first, the new one second
+			name:      "passwords in the wrong order",
+			args:      []string{"--out", out, keystoreFile},
+			passwords: []string{"Rotated1!pass", "Current1!pass"},
+			wantErr:   true,
+		},
+		{
+			name:      "weak new password",
+			args:      []string{"--out", out, keystoreFile},
+			passwords: []string{"Current1!pass", "weak"},
+			wantErr:   true,
+		},
+		{
+			name:      "keep the key derivation",
+			args:      []string{"--json", "--out", out, keystoreFile},
+			passwords: []string{"Current1!pass", "Rotated1!pass"},
+			check: func(t *testing.T, stdout string) {
+				var result keystoreFileResult
+				decodeJSON(t, stdout, &result)
+				if result.Address != address || result.File != out || result.KDF == nil || result.KDF.Profile != "light" {
+					t.Errorf("❌ Unexpected result %+v", result)
+				}
+				checkKeystore(t, out, "Rotated1!pass", "scrypt")
+			},
+		},
+		{
+			name:      "existing output file",
+			args:      []string{"--out", out, keystoreFile},
+			passwords: []string{"Current1!pass", "Other1!pass"},
+			wantErr:   true,
+		},
+		{
+			name:      "switch to pbkdf2",
+			args:      []string{"--kdf", "pbkdf2", "--out", pbkdf2Out, out},
+			passwords: []string{"Rotated1!pass", "Rotated2!pass"},
+			check: func(t *testing.T, stdout string) {
+				checkKeystore(t, pbkdf2Out, "Rotated2!pass", "pbkdf2")
+			},
+		},
+		{
+			name:    "unknown key derivation",
+			args:    []string{"--kdf", "argon2", "--out", filepath.Join(dir, "argon2.json"), keystoreFile},
+			wantErr: true,
+		},
+		{name: "missing --out", args: []string{keystoreFile}, wantErr: true},
+	})
+
+	// The failed runs must not have replaced the first rotated keystore
+	checkKeystore(t, out, "Rotated1!pass", "scrypt")
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCLIDerive(t *testing.T) {
+	fmt.Println("=== CLI Derive Test ===")
+
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	const firstAddress = "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+	dir := t.TempDir()
+
+	runCLICases(t, runDeriveCommand, []cliCase{
+		{
+			name:      "list accounts as JSON",
+			args:      []string{"--json", "--count", "3", "--out-dir", dir},
+			passwords: []string{mnemonic},
+			check: func(t *testing.T, stdout string) {
+				var accounts []derivedAccountResult
+				decodeJSON(t, stdout, &accounts)
+				if len(accounts) != 3 || accounts[0].Address != firstAddress || accounts[2].Path != "m/44'/60'/0'/0/2" || accounts[0].File != "" {
+					t.Errorf("❌ Unexpected accounts %+v", accounts)
+				}
+				if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+					t.Errorf("❌ Listing wrote %d files", len(files))
+				}
+			},
+		},
+		{
+			// The passphrase is read after the mnemonic
+			name:      "passphrase",
+			args:      []string{"--json", "--count", "1", "--passphrase"},
+			passwords: []string{mnemonic, "TREZOR"},
+			check: func(t *testing.T, stdout string) {
+				var accounts []derivedAccountResult
+				decodeJSON(t, stdout, &accounts)
+				if len(accounts) != 1 || accounts[0].Address == firstAddress {
+					t.Errorf("❌ Passphrase did not change the account: %+v", accounts)
+				}
+			},
+		},
+		{
+			name:      "write keystores",
+			args:      []string{"--json", "--count", "2", "--keystores", "--kdf", "light", "--out-dir", dir},
+			passwords: []string{mnemonic, "Derive1!pass"},
+			check: func(t *testing.T, stdout string) {
+				var accounts []derivedAccountResult
+				decodeJSON(t, stdout, &accounts)
+				if len(accounts) != 2 {
+					t.Fatalf("❌ Unexpected accounts %+v", accounts)
+				}
+				for _, account := range accounts {
+					content, err := os.ReadFile(account.File)
+					if err != nil {
+						t.Fatal("Failed to read keystore:", err)
+					}
+					key, err := keystore.DecryptKey(content, "Derive1!pass")
+					if err != nil || key.Address.Hex() != account.Address {
+						t.Errorf("❌ %s does not hold %s: %v", account.File, account.Address, err)
+					}
+				}
+			},
+		},
+		{
+			name:      "existing keystores",
+			args:      []string{"--count", "1", "--keystores", "--kdf", "light", "--out-dir", dir},
+			passwords: []string{mnemonic, "Derive1!pass"},
+			wantErr:   true,
+		},
+		{
+			// The keystore password is read last, after the mnemonic
+			name:      "password before mnemonic",
+			args:      []string{"--count", "1", "--keystores", "--out-dir", t.TempDir()},
+			passwords: []string{"Derive1!pass", mnemonic},
+			wantErr:   true,
+		},
+		{name: "invalid path", args: []string{"--path", "m/44'/x"}, passwords: []string{mnemonic}, wantErr: true},
+		{name: "unknown key derivation", args: []string{"--kdf", "argon2"}, wantErr: true},
+	})
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCLISLIP39Split(t *testing.T) {
+	fmt.Println("=== CLI SLIP-39 Split Test ===")
+
+	// wordCounts checks the groups of a slip39-split JSON result
+	wordCounts := func(groups []int, words int) func(t *testing.T, stdout string) {
+		return func(t *testing.T, stdout string) {
+			var result slip39SplitResult
+			decodeJSON(t, stdout, &result)
+			if len(result.Groups) != len(groups) || result.Address == "" {
+				t.Fatalf("❌ Unexpected result %+v", result)
+			}
+			for i, group := range result.Groups {
+				if len(group) != groups[i] {
+					t.Errorf("❌ Group %d has %d shares, expected %d", i+1, len(group), groups[i])
+				}
+				for _, mnemonic := range group {
+					if n := len(strings.Fields(mnemonic)); n != words {
+						t.Errorf("❌ Share has %d words, expected %d", n, words)
+					}
+				}
+			}
+		}
+	}
+
+	runCLICases(t, runSLIP39SplitCommand, []cliCase{
+		{name: "one group", args: []string{"--json", "--groups", "2/3"}, check: wordCounts([]int{3}, 20)},
+		{name: "256 bit secret", args: []string{"--json", "--groups", "2/3", "--strength", "256"}, check: wordCounts([]int{3}, 33)},
+		{
+			name:      "groups with a passphrase",
+			args:      []string{"--json", "--groups", "1/1,2/3", "--group-threshold", "2", "--passphrase"},
+			passwords: []string{"slip39 passphrase"},
+			check:     wordCounts([]int{1, 3}, 20),
+		},
+		{name: "missing groups", args: []string{"--json"}, wantErr: true},
+		{name: "threshold above count", args: []string{"--groups", "3/2"}, wantErr: true},
+		{name: "invalid strength", args: []string{"--groups", "2/3", "--strength", "100"}, wantErr: true},
+	})
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCLISLIP39Combine(t *testing.T) {
+	fmt.Println("=== CLI SLIP-39 Combine Test ===")
+
+	dir := t.TempDir()
+	split, err := NewApp().GenerateSLIP39Shares(128, "slip39 passphrase", 1, []SLIP39Group{{Threshold: 2, Count: 3}})
+	if err != nil {
+		t.Fatal("Failed to split:", err)
+	}
+	twoShares := filepath.Join(dir, "two.txt")
+	oneShare := filepath.Join(dir, "one.txt")
+	if err := os.WriteFile(twoShares, []byte(split.Groups[0][0]+"\n\n"+split.Groups[0][2]+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(oneShare, []byte(split.Groups[0][1]+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "recovered.json")
+
+	runCLICases(t, runSLIP39CombineCommand, []cliCase{
+		{
+			name:      "too few shares",
+			args:      []string{"--passphrase", "--out", out, oneShare},
+			passwords: []string{"slip39 passphrase", "Recover1!pass"},
+			wantErr:   true,
+		},
+		{
+			// The passphrase is read before the keystore password
+			name:      "password before passphrase",
+			args:      []string{"--passphrase", "--out", out, twoShares},
+			passwords: []string{"Recover1!pass", "slip39 passphrase"},
+			wantErr:   true,
+		},
+		{
+			name:      "recover as JSON",
+			args:      []string{"--json", "--passphrase", "--kdf", "light", "--out", out, twoShares},
+			passwords: []string{"slip39 passphrase", "Recover1!pass"},
+			check: func(t *testing.T, stdout string) {
+				var result keystoreFileResult
+				decodeJSON(t, stdout, &result)
+				if result.Address != split.Address || result.File != out || result.Seed == "" || result.KDF == nil || result.KDF.Profile != "light" {
+					t.Errorf("❌ Unexpected result %+v", result)
+				}
+				content, err := os.ReadFile(out)
+				if err != nil {
+					t.Fatal("Failed to read keystore:", err)
+				}
+				if key, err := keystore.DecryptKey(content, "Recover1!pass"); err != nil || key.Address.Hex() != split.Address {
+					t.Errorf("❌ Recovered keystore does not hold %s: %v", split.Address, err)
+				}
+			},
+		},
+		{
+			name:      "existing output file",
+			args:      []string{"--passphrase", "--out", out, twoShares},
+			passwords: []string{"slip39 passphrase", "Recover1!pass"},
+			wantErr:   true,
+		},
+		{name: "no share files", args: []string{"--out", out}, wantErr: true},
+	})
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCLIVerifyShare(t *testing.T) {
+	fmt.Println("=== CLI Verify Share Test ===")
+
+	verifiableDir, plainDir := t.TempDir(), t.TempDir()
+	fd := passwordPipe(t, "Share1!pass", "Share2!pass")
+	if err := runSplitCommand([]string{"--shares", "2", "--threshold", "2", "--verifiable", "--password-fd", fd, "--out-dir", verifiableDir}); err != nil {
+		t.Fatal("Failed to split verifiable shares:", err)
+	}
+	fd = passwordPipe(t, "Share1!pass", "Share2!pass")
+	if err := runSplitCommand([]string{"--shares", "2", "--threshold", "2", "--password-fd", fd, "--out-dir", plainDir}); err != nil {
+		t.Fatal("Failed to split shares:", err)
+	}
+	verifiable, _ := filepath.Glob(filepath.Join(verifiableDir, "*_sharekey_2.json"))
+	plain, _ := filepath.Glob(filepath.Join(plainDir, "*_sharekey_2.json"))
+	if len(verifiable) != 1 || len(plain) != 1 {
+		t.Fatalf("Expected one share file of each split, got %v and %v", verifiable, plain)
+	}
+	address := "0x" + strings.Split(filepath.Base(verifiable[0]), "_")[0]
+
+	runCLICases(t, runVerifyShareCommand, []cliCase{
+		{
+			name:      "verified as JSON",
+			args:      []string{"--json", verifiable[0]},
+			passwords: []string{"Share2!pass"},
+			check: func(t *testing.T, stdout string) {
+				var result DecryptedShareResult
+				decodeJSON(t, stdout, &result)
+				if result.ShareIndex != 2 || !strings.EqualFold(result.Address, address) || result.ShareSet == nil || len(result.ShareSet.Commitments) != 2 {
+					t.Errorf("❌ Unexpected result %+v", result)
+				}
+			},
+		},
+		{
+			name:      "verified as text",
+			args:      []string{verifiable[0]},
+			passwords: []string{"Share2!pass"},
+			check: func(t *testing.T, stdout string) {
+				if !strings.Contains(stdout, "share matches the commitments") {
+					t.Errorf("❌ Unexpected output %q", stdout)
+				}
+			},
+		},
+		{name: "wrong password", args: []string{verifiable[0]}, passwords: []string{"Share1!pass"}, wantErr: true},
+		{name: "share without commitments", args: []string{plain[0]}, passwords: []string{"Share2!pass"}, wantErr: true},
+		{name: "two files", args: []string{verifiable[0], plain[0]}, wantErr: true},
+	})
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+// splitShareFiles splits a new key 3 of 2 with the CLI and returns the share
+// files, whose passwords are Share1!pass to Share3!pass
+func splitShareFiles(t *testing.T, dir string) []string {
+	fd := passwordPipe(t, "Share1!pass", "Share2!pass", "Share3!pass")
+	if err := runSplitCommand([]string{"--shares", "3", "--threshold", "2", "--password-fd", fd, "--out-dir", dir}); err != nil {
+		t.Fatal("Failed to split:", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*_sharekey_*.json"))
+	if err != nil || len(files) != 3 {
+		t.Fatalf("Expected 3 share files, got %d (%v)", len(files), err)
+	}
+	return files
+}
+
+func TestCLIReshare(t *testing.T) {
+	fmt.Println("=== CLI Reshare Test ===")
+
+	shareFiles := splitShareFiles(t, t.TempDir())
+	content, err := os.ReadFile(shareFiles[0])
+	if err != nil {
+		t.Fatal("Failed to read share:", err)
+	}
+	old, err := keystorefmt.Inspect(content)
+	if err != nil {
+		t.Fatal("Failed to inspect share:", err)
+	}
+	newDir := t.TempDir()
+
+	runCLICases(t, runReshareCommand, []cliCase{
+		{
+			// Old share passwords are read first, then one per new share
+			name:      "new passwords before old ones",
+			args:      []string{"--shares", "3", "--threshold", "3", "--out-dir", t.TempDir(), shareFiles[0], shareFiles[2]},
+			passwords: []string{"New1!pass", "New2!pass", "New3!pass", "Share1!pass", "Share3!pass"},
+			wantErr:   true,
+		},
+		{
+			name:      "weak new password",
+			args:      []string{"--shares", "3", "--threshold", "3", "--out-dir", t.TempDir(), shareFiles[0], shareFiles[2]},
+			passwords: []string{"Share1!pass", "Share3!pass", "weak"},
+			wantErr:   true,
+		},
+		{
+			name:      "reshare as JSON",
+			args:      []string{"--json", "--kdf", "light", "--shares", "3", "--threshold", "3", "--out-dir", newDir, shareFiles[0], shareFiles[2]},
+			passwords: []string{"Share1!pass", "Share3!pass", "New1!pass", "New2!pass", "New3!pass"},
+			check: func(t *testing.T, stdout string) {
+				var result splitResult
+				decodeJSON(t, stdout, &result)
+				if result.Address != old.Address || result.SetID == old.ShareSet.ID || result.Shares != 3 || result.Threshold != 3 || len(result.Files) != 3 ||
+					result.KDF == nil || result.KDF.Profile != "light" {
+					t.Fatalf("❌ Unexpected result %+v", result)
+				}
+				content, err := os.ReadFile(result.Files[1])
+				if err != nil {
+					t.Fatal("Failed to read new share:", err)
+				}
+				share, err := keystorefmt.DecryptShare(content, "New2!pass")
+				if err != nil || share.Set.ID != result.SetID {
+					t.Errorf("❌ New share 2 does not open with its password: %v", err)
+				}
+				if kdf, err := keystorefmt.KeystoreKDF(content); err != nil || kdf != keystorefmt.KDFLight {
+					t.Errorf("❌ New share 2 uses %+v: %v", kdf, err)
+				}
+			},
+		},
+		{
+			name:      "existing share files",
+			args:      []string{"--shares", "3", "--threshold", "3", "--out-dir", newDir, shareFiles[0], shareFiles[2]},
+			passwords: []string{"Share1!pass", "Share3!pass", "New1!pass", "New2!pass", "New3!pass"},
+			wantErr:   true,
+		},
+		{name: "missing --out-dir", args: []string{"--shares", "3", "--threshold", "2", shareFiles[0]}, wantErr: true},
+		{name: "threshold above count", args: []string{"--shares", "2", "--threshold", "3", "--out-dir", t.TempDir(), shareFiles[0]}, wantErr: true},
+		{name: "unknown key derivation", args: []string{"--kdf", "argon2", "--shares", "3", "--threshold", "2", "--out-dir", t.TempDir(), shareFiles[0]}, wantErr: true},
+	})
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCLIExtend(t *testing.T) {
+	fmt.Println("=== CLI Extend Test ===")
+
+	shareFiles := splitShareFiles(t, t.TempDir())
+	oldShare, err := os.ReadFile(shareFiles[2])
+	if err != nil {
+		t.Fatal("Failed to read share:", err)
+	}
+	old, err := keystorefmt.Inspect(oldShare)
+	if err != nil {
+		t.Fatal("Failed to inspect share:", err)
+	}
+	newDir := t.TempDir()
+
+	runCLICases(t, runExtendCommand, []cliCase{
+		{
+			// Existing share passwords are read first, then one per new share
+			name:      "new password before old ones",
+			args:      []string{"--count", "1", "--out-dir", t.TempDir(), shareFiles[0], shareFiles[1]},
+			passwords: []string{"New4!pass", "Share1!pass", "Share2!pass"},
+			wantErr:   true,
+		},
+		{
+			name:      "extend as JSON",
+			args:      []string{"--json", "--count", "2", "--out-dir", newDir, shareFiles[0], shareFiles[1]},
+			passwords: []string{"Share1!pass", "Share2!pass", "New4!pass", "New5!pass"},
+			check: func(t *testing.T, stdout string) {
+				var result splitResult
+				decodeJSON(t, stdout, &result)
+				if result.Address != old.Address || result.SetID != old.ShareSet.ID || len(result.Files) != 2 ||
+					len(result.Indexes) != 2 || result.Indexes[0] != 4 || result.Indexes[1] != 5 {
+					t.Fatalf("❌ Unexpected result %+v", result)
+				}
+
+				// A new share combines with an old one of the same set
+				newShare, err := os.ReadFile(result.Files[0])
+				if err != nil {
+					t.Fatal("Failed to read new share:", err)
+				}
+				recovered, err := NewApp().RecoverFromShareKeystores([]string{string(newShare), string(oldShare)},
+					[]string{"New4!pass", "Share3!pass"}, "Recover1!pass")
+				if err != nil || recovered.Address != old.Address {
+					t.Errorf("❌ New and old shares do not recover %s: %v", old.Address, err)
+				}
+			},
+		},
+		{
+			name:      "existing share files",
+			args:      []string{"--count", "2", "--out-dir", newDir, shareFiles[0], shareFiles[1]},
+			passwords: []string{"Share1!pass", "Share2!pass", "New4!pass", "New5!pass"},
+			wantErr:   true,
+		},
+		{name: "zero shares", args: []string{"--count", "0", "--out-dir", t.TempDir(), shareFiles[0]}, wantErr: true},
+		{name: "missing --out-dir", args: []string{"--count", "1", shareFiles[0]}, wantErr: true},
+	})
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCLIUnwrapShare(t *testing.T) {
+	fmt.Println("=== CLI Unwrap Share Test ===")
+
+	dir := t.TempDir()
+	var keyFiles, publicKeys []string
+	for _, password := range []string{"Custodian1!pass", "Custodian2!pass"} {
+		keyFile, _ := writeTestKeystore(t, dir, password)
+		keystoreJSON, err := os.ReadFile(keyFile)
+		if err != nil {
+			t.Fatal("Failed to read custodian keystore:", err)
+		}
+		custodian, err := NewApp().ImportKeystore(string(keystoreJSON), password)
+		if err != nil {
+			t.Fatal("Failed to import custodian keystore:", err)
+		}
+		keyFiles = append(keyFiles, keyFile)
+		publicKeys = append(publicKeys, custodian.PublicKey)
+	}
+
+	// The third custodian holds an X25519 key
+	x25519Key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("Failed to generate X25519 key:", err)
+	}
+	x25519File := filepath.Join(dir, "custodian_x25519.key")
+	if err := writeNewFile(x25519File, hex.EncodeToString(x25519Key.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	publicKeys = append(publicKeys, hex.EncodeToString(x25519Key.PublicKey().Bytes()))
+
+	recipientsFile := filepath.Join(dir, "recipients.txt")
+	if err := writeNewFile(recipientsFile, strings.Join(publicKeys, "\n")); err != nil {
+		t.Fatal(err)
+	}
+	shareDir := t.TempDir()
+	if err := runSplitCommand([]string{"--shares", "3", "--threshold", "2", "--recipients", recipientsFile,
+		"--password-fd", passwordPipe(t), "--out-dir", shareDir}); err != nil {
+		t.Fatal("Failed to split:", err)
+	}
+	shareFiles, err := filepath.Glob(filepath.Join(shareDir, "*_sharekey_*.json"))
+	if err != nil || len(shareFiles) != 3 {
+		t.Fatalf("Expected 3 share files, got %d (%v)", len(shareFiles), err)
+	}
+	out := filepath.Join(t.TempDir(), "share1.json")
+
+	runCLICases(t, runUnwrapShareCommand, []cliCase{
+		{
+			// The custodian keystore password is read first, then the new one
+			name:      "share password before custodian password",
+			args:      []string{"--key", keyFiles[0], "--out", filepath.Join(t.TempDir(), "share.json"), shareFiles[0]},
+			passwords: []string{"Share1!pass", "Custodian1!pass"},
+			wantErr:   true,
+		},
+		{
+			name:      "other custodian key",
+			args:      []string{"--key", keyFiles[1], "--out", filepath.Join(t.TempDir(), "share.json"), shareFiles[0]},
+			passwords: []string{"Custodian2!pass", "Share1!pass"},
+			wantErr:   true,
+		},
+		{
+			name:      "unwrap as JSON",
+			args:      []string{"--json", "--key", keyFiles[0], "--out", out, shareFiles[0]},
+			passwords: []string{"Custodian1!pass", "Share1!pass"},
+			check: func(t *testing.T, stdout string) {
+				var result shareFileResult
+				decodeJSON(t, stdout, &result)
+				if result.Index != 1 || result.File != out {
+					t.Fatalf("❌ Unexpected result %+v", result)
+				}
+
+				content, err := os.ReadFile(out)
+				if err != nil {
+					t.Fatal("Failed to read unwrapped share:", err)
+				}
+				if _, err := keystorefmt.DecryptShare(content, "Share1!pass"); err != nil {
+					t.Errorf("❌ Unwrapped share does not decrypt: %v", err)
+				}
+			},
+		},
+		{
+			name:      "existing out file",
+			args:      []string{"--key", keyFiles[0], "--out", out, shareFiles[0]},
+			passwords: []string{"Custodian1!pass", "Share1!pass"},
+			wantErr:   true,
+		},
+		{
+			// An X25519 key file needs no custodian password
+			name:      "unwrap with an X25519 key",
+			args:      []string{"--json", "--x25519-key", x25519File, "--out", filepath.Join(t.TempDir(), "share3.json"), shareFiles[2]},
+			passwords: []string{"Share3!pass"},
+			check: func(t *testing.T, stdout string) {
+				var result shareFileResult
+				decodeJSON(t, stdout, &result)
+				content, err := os.ReadFile(result.File)
+				if err != nil {
+					t.Fatal("Failed to read unwrapped share:", err)
+				}
+				if share, err := keystorefmt.DecryptShare(content, "Share3!pass"); err != nil || share.Index != 3 || result.Index != 3 {
+					t.Errorf("❌ Unwrapped share %d does not decrypt: %v", result.Index, err)
+				}
+			},
+		},
+		{
+			name:      "X25519 key for a secp256k1 share",
+			args:      []string{"--x25519-key", x25519File, "--out", filepath.Join(t.TempDir(), "share.json"), shareFiles[1]},
+			passwords: []string{"Share2!pass"},
+			wantErr:   true,
+		},
+		{name: "missing --key", args: []string{"--out", filepath.Join(t.TempDir(), "share.json"), shareFiles[0]}, wantErr: true},
+		{
+			name:    "both --key and --x25519-key",
+			args:    []string{"--key", keyFiles[0], "--x25519-key", x25519File, "--out", filepath.Join(t.TempDir(), "share.json"), shareFiles[0]},
+			wantErr: true,
+		},
+	})
+
+	fmt.Println("\n=== Test Complete ===")
+}
@@ -1,7 +1,7 @@
 import './style.css'
 
 // Wails API 가져오기
-import { GenerateKey, GenerateShamirShares, CreateShareKeystore, CombineShamirShares, OpenDownloadFolder, GetDownloadPath, SaveFileToDownloads } from '../wailsjs/go/main/App'
+import { GenerateKey, GenerateMnemonicKey, ImportMnemonic, DeriveMnemonicKeys, GenerateShamirShares, GenerateVerifiableShares, GeneratePolicyShares, GenerateMnemonicShares, SplitMnemonic, ImportKeystore, RevealKeystorePrivateKey, SplitKeystore, SplitPrivateKey, CreateShareKeystore, VerifyShamirShares, VerifyShareKeystore, CreateCustodianShare, UnwrapCustodianShare, UnwrapX25519Share, RecoverSecretFromShareKeystores, ReshareShareKeystores, ExtendShareKeystores, GenerateSLIP39Shares, RecoverSLIP39Shares, ChangeKeystorePassword, SetKDFProfile, OpenDownloadFolder, GetDownloadPath, SaveFileToDownloads } from '../wailsjs/go/main/App'
 
 // DOM 요소들
 const passwordInput = document.getElementById('password')
@@ -13,6 +13,38 @@ const generateBtn = document.getElementById('generateBtn')
 const resultsSection = document.getElementById('resultsSection')
 const sharesTab = document.getElementById('sharesTab')
 
+// 니모닉 관련 요소들
+const keyTypeSelect = document.getElementById('keyType')
+const keyTypeGroup = document.getElementById('keyTypeGroup')
+const mnemonicImportGroup = document.getElementById('mnemonicImportGroup')
+const mnemonicInput = document.getElementById('mnemonicInput')
+const kdfProfileSelect = document.getElementById('kdfProfile')
+const passphraseGroup = document.getElementById('passphraseGroup')
+const keystoreImportGroup = document.getElementById('keystoreImportGroup')
+const privateKeyImportGroup = document.getElementById('privateKeyImportGroup')
+const privateKeyInput = document.getElementById('privateKeyInput')
+const keystoreFileInput = document.getElementById('keystoreFile')
+const keystorePasswordInput = document.getElementById('keystorePassword')
+const mnemonicPassphraseInput = document.getElementById('mnemonicPassphrase')
+const mnemonicTab = document.getElementById('mnemonicTab')
+const mnemonicContent = document.getElementById('mnemonicContent')
+const mnemonicPathContent = document.getElementById('mnemonicPathContent')
+const splitSecretGroup = document.getElementById('splitSecretGroup')
+const splitSecretSelect = document.getElementById('splitSecret')
+const verifiableGroup = document.getElementById('verifiableGroup')
+const verifiableSharesInput = document.getElementById('verifiableShares')
+const policyGroup = document.getElementById('policyGroup')
+const usePolicyInput = document.getElementById('usePolicy')
+const policyInputs = document.getElementById('policyInputs')
+const policyGroupsInput = document.getElementById('policyGroups')
+const policyGroupThresholdInput = document.getElementById('policyGroupThreshold')
+const deriveSection = document.getElementById('deriveSection')
+const derivePathInput = document.getElementById('derivePath')
+const deriveStartInput = document.getElementById('deriveStart')
+const deriveCountInput = document.getElementById('deriveCount')
+const deriveBtn = document.getElementById('deriveBtn')
+const derivedAccountList = document.getElementById('derivedAccountList')
+
 // Share key 관련 요소들
 const shareListElement = document.getElementById('shareList')
 const recoveredAddressElement = document.getElementById('recoveredAddress')
@@ -20,6 +52,8 @@ const recoveredAddressElement = document.getElementById('recoveredAddress')
 // 개인키 관련 요소들
 const revealPrivateKeyBtn = document.getElementById('revealPrivateKeyBtn')
 const privateKeyText = document.getElementById('privateKeyText')
+const revealPasswordGroup = document.getElementById('revealPasswordGroup')
+const revealKeystorePasswordInput = document.getElementById('revealKeystorePassword')
 const copyPrivateKeyBtn = document.getElementById('copyPrivateKeyBtn')
 const downloadPrivateKeyBtn = document.getElementById('downloadPrivateKeyBtn')
 
@@ -29,10 +63,44 @@ const tabPanes = document.querySelectorAll('.tab-pane')
 
 // 결과 내용 요소들
 const keystoreContent = document.getElementById('keystoreContent')
+const keystoreKdfContent = document.getElementById('keystoreKdfContent')
 const publicKeyContent = document.getElementById('publicKeyContent')
 const privateKeyContent = document.getElementById('privateKeyContent')
 const addressContent = document.getElementById('addressContent')
 
+// 공유 키 복구 관련 요소들
+const shareFilesInput = document.getElementById('shareFiles')
+const recoveryShareListElement = document.getElementById('recoveryShareList')
+const recoveryPassphraseInput = document.getElementById('recoveryPassphrase')
+const recoveryPasswordInput = document.getElementById('recoveryPassword')
+const recoveryConfirmPasswordInput = document.getElementById('recoveryConfirmPassword')
+const recoverBtn = document.getElementById('recoverBtn')
+const reshareTotalSharesInput = document.getElementById('reshareTotalShares')
+const reshareThresholdInput = document.getElementById('reshareThreshold')
+const reshareBtn = document.getElementById('reshareBtn')
+const extendCountInput = document.getElementById('extendCount')
+const extendBtn = document.getElementById('extendBtn')
+
+// SLIP-39 관련 요소들
+const slip39StrengthSelect = document.getElementById('slip39Strength')
+const slip39GroupsInput = document.getElementById('slip39Groups')
+const slip39GroupThresholdInput = document.getElementById('slip39GroupThreshold')
+const slip39PassphraseInput = document.getElementById('slip39Passphrase')
+const slip39GenerateBtn = document.getElementById('slip39GenerateBtn')
+const slip39ShareList = document.getElementById('slip39ShareList')
+const slip39RecoverySharesInput = document.getElementById('slip39RecoveryShares')
+const slip39RecoveryPassphraseInput = document.getElementById('slip39RecoveryPassphrase')
+const slip39RecoveryPasswordInput = document.getElementById('slip39RecoveryPassword')
+const slip39RecoverBtn = document.getElementById('slip39RecoverBtn')
+
+// 키스토어 비밀번호 변경 요소들
+const changeKeystoreFileInput = document.getElementById('changeKeystoreFile')
+const changeOldPasswordInput = document.getElementById('changeOldPassword')
+const changeNewPasswordInput = document.getElementById('changeNewPassword')
+const changeConfirmPasswordInput = document.getElementById('changeConfirmPassword')
+const changeKdfProfileSelect = document.getElementById('changeKdfProfile')
+const changePasswordBtn = document.getElementById('changePasswordBtn')
+
 // 액션 버튼들
 const copyBtn = document.getElementById('copyBtn')
 const downloadBtn = document.getElementById('downloadBtn')
@@ -41,6 +109,9 @@ const downloadBtn = document.getElementById('downloadBtn')
 let currentTab = 'keystore'
 let currentResult = null
 let privateKeyRevealed = false
+let recoveryShareFiles = []
+let currentPassphrase = ''
+let importedKeystore = null // 가져온 키스토어 (비밀번호는 보관하지 않고 개인키 노출 시 다시 입력받음)
 
 // 이벤트 리스너들
 generateBtn.addEventListener('click', handleGenerate)
@@ -52,12 +123,33 @@ revealPrivateKeyBtn.addEventListener('click', handleRevealPrivateKey)
 copyPrivateKeyBtn.addEventListener('click', handleCopyPrivateKey)
 downloadPrivateKeyBtn.addEventListener('click', handleDownloadPrivateKey)
 
+// 공유 키 복구 이벤트 리스너
+shareFilesInput.addEventListener('change', handleShareFilesSelected)
+recoveryPasswordInput.addEventListener('input', updateRecoverButton)
+recoveryConfirmPasswordInput.addEventListener('input', updateRecoverButton)
+recoverBtn.addEventListener('click', handleRecover)
+reshareBtn.addEventListener('click', handleReshare)
+extendBtn.addEventListener('click', handleExtendShares)
+
+// HD 계정 조회 이벤트
+deriveBtn.addEventListener('click', handleDeriveAccounts)
+
+// SLIP-39 이벤트
+slip39GenerateBtn.addEventListener('click', handleGenerateSLIP39)
+slip39RecoverBtn.addEventListener('click', handleRecoverSLIP39)
+
+// 키스토어 비밀번호 변경 이벤트
+changePasswordBtn.addEventListener('click', handleChangeKeystorePassword)
+
 // 비밀번호 확인 이벤트 리스너
 passwordInput.addEventListener('input', checkPasswordMatch)
 confirmPasswordInput.addEventListener('input', checkPasswordMatch)
 
 // 공유 키 설정 변경 이벤트 리스너
 totalSharesInput.addEventListener('input', updateUIForShareCount)
+keyTypeSelect.addEventListener('change', updateUIForKeyType)
+kdfProfileSelect.addEventListener('change', handleKDFProfileChange)
+usePolicyInput.addEventListener('change', updateUIForKeyType)
 thresholdInput.addEventListener('input', updateUIForShareCount)
 
 // 탭 버튼 이벤트 리스너
@@ -170,10 +262,22 @@ function checkPasswordMatch() {
 // 키 생성 처리
 async function handleGenerate() {
     const totalShares = parseInt(totalSharesInput.value)
-    const threshold = parseInt(thresholdInput.value)
+    const threshold = parseInt(thresholdInput.value) || 0
     
     console.log('키 생성 시작:', { totalShares, threshold })
+
+    // 기존 키스토어 가져오기는 새 비밀번호 없이 처리
+    if (keyTypeSelect.value === 'importKeystore') {
+        await handleImportKeystore(totalShares, threshold)
+        return
+    }
     
+    // 기존 개인키는 공유 키로만 분할
+    if (keyTypeSelect.value === 'importPrivateKey' && totalShares === 1) {
+        alert('기존 개인키를 분할하려면 총 개수를 2 이상으로 설정하세요.')
+        return
+    }
+
     // 총 개수가 1일 때 (일반 키스토어)
     if (totalShares === 1) {
         console.log('일반 키스토어 모드')
@@ -201,8 +305,18 @@ async function handleGenerate() {
             generateBtn.disabled = true
             generateBtn.textContent = '생성 중...'
 
-            // 일반 키 생성
-            const result = await GenerateKey(password)
+            // 키 유형에 따라 일반 키 또는 니모닉 키 생성
+            const keyType = keyTypeSelect.value
+            const passphrase = mnemonicPassphraseInput.value
+            let result
+            if (keyType === 'mnemonic12' || keyType === 'mnemonic24') {
+                result = await GenerateMnemonicKey(password, keyType === 'mnemonic12' ? 12 : 24, passphrase)
+            } else if (keyType === 'importMnemonic') {
+                result = await ImportMnemonic(mnemonicInput.value, passphrase, password)
+            } else {
+                result = await GenerateKey(password)
+            }
+            currentPassphrase = passphrase
             sharesTab.style.display = 'none'
             displayResults(result, false)
             
@@ -218,30 +332,39 @@ async function handleGenerate() {
     } else {
         console.log('공유 키 모드')
         // 공유 키 모드 - 비밀번호 검증 없이 바로 생성
-        if (totalShares < 2 || totalShares > 10) {
-            alert('총 공유 키 개수는 2-10 사이여야 합니다.')
-            return
-        }
-
-        if (threshold < 2 || threshold > totalShares) {
-            alert('복구에 필요한 최소 개수는 2-총 개수 사이여야 합니다.')
-            return
-        }
-
+        // 총 개수(2-255)와 최소 개수(2-총 개수)는 Go API에서 검증
         try {
             console.log('공유 키 생성 시작')
             generateBtn.disabled = true
             generateBtn.textContent = '생성 중...'
 
-            // 공유 키 생성 (임시 비밀번호 사용)
-            const result = await GenerateShamirShares("temp_password", totalShares, threshold)
+            // 키 유형에 따라 랜덤 개인키 또는 니모닉을 분할 (임시 비밀번호 사용)
+            const keyType = keyTypeSelect.value
+            const passphrase = mnemonicPassphraseInput.value
+            const secretKind = splitSecretSelect.value
+            let result
+            if (keyType === 'mnemonic12' || keyType === 'mnemonic24') {
+                result = await GenerateMnemonicShares(keyType === 'mnemonic12' ? 12 : 24, passphrase, secretKind, totalShares, threshold)
+            } else if (keyType === 'importMnemonic') {
+                result = await SplitMnemonic(mnemonicInput.value, passphrase, secretKind, totalShares, threshold)
+            } else if (keyType === 'importPrivateKey') {
+                result = await SplitPrivateKey(privateKeyInput.value, totalShares, threshold)
+                privateKeyInput.value = ''
+            } else if (usePolicyInput.checked) {
+                result = await GeneratePolicyShares(parseInt(policyGroupThresholdInput.value) || 0, parsePolicyGroups(policyGroupsInput.value))
+            } else if (verifiableSharesInput.checked) {
+                result = await GenerateVerifiableShares(totalShares, threshold)
+            } else {
+                result = await GenerateShamirShares("temp_password", totalShares, threshold)
+            }
+            currentPassphrase = passphrase
             console.log('공유 키 생성 완료:', result)
             sharesTab.style.display = 'block'
             displayResults(result, true)
             
         } catch (error) {
             console.error('공유 키 생성 오류:', error)
-            alert('공유 키 생성 중 오류가 발생했습니다: ' + error.message)
+            alert('공유 키 생성 중 오류가 발생했습니다: ' + (error.message || error))
         } finally {
             generateBtn.disabled = false
             generateBtn.textContent = '키 생성'
@@ -249,6 +372,233 @@ async function handleGenerate() {
     }
 }
 
+// "임원:2/3,보안:1/4" 형식의 그룹 목록을 변환 (개수와 이름은 Go API에서 검증)
+function parsePolicyGroups(text) {
+    return text.split(',').map((spec, i) => {
+        const [name, counts] = spec.includes(':') ? spec.split(':') : [`group${i + 1}`, spec]
+        const [threshold, count] = (counts || '').split('/')
+        return { name: name.trim(), threshold: parseInt(threshold) || 0, count: parseInt(count) || 0 }
+    })
+}
+
+// 기존 키스토어를 가져와 주소를 확인하거나 공유 키로 분할
+async function handleImportKeystore(totalShares, threshold) {
+    const file = keystoreFileInput.files[0]
+    if (!file) {
+        alert('가져올 키스토어 파일을 선택해주세요.')
+        return
+    }
+
+    try {
+        generateBtn.disabled = true
+        generateBtn.textContent = '가져오는 중...'
+
+        const keystoreJSON = await file.text()
+        const password = keystorePasswordInput.value
+        let result
+        if (totalShares === 1) {
+            result = await ImportKeystore(keystoreJSON, password)
+        } else {
+            result = await SplitKeystore(keystoreJSON, password, totalShares, threshold)
+        }
+        importedKeystore = { keystore: keystoreJSON, address: result.address }
+        keystorePasswordInput.value = ''
+        currentPassphrase = ''
+        sharesTab.style.display = totalShares === 1 ? 'none' : 'block'
+        displayResults(result, totalShares !== 1)
+    } catch (error) {
+        console.error('키스토어 가져오기 오류:', error)
+        alert('키스토어를 가져오는 중 오류가 발생했습니다: ' + (error.message || error))
+    } finally {
+        generateBtn.disabled = false
+        generateBtn.textContent = '키 생성'
+    }
+}
+
+// 니모닉의 HD 계정 주소 목록 조회 (키스토어는 생성하지 않음)
+async function handleDeriveAccounts() {
+    if (!currentResult || !currentResult.mnemonic) {
+        return
+    }
+
+    const start = parseInt(deriveStartInput.value)
+    const count = parseInt(deriveCountInput.value)
+    if (isNaN(start) || start < 0 || isNaN(count) || count < 1 || count > 100) {
+        alert('시작 인덱스는 0 이상, 계정 개수는 1-100 사이여야 합니다.')
+        return
+    }
+
+    try {
+        deriveBtn.disabled = true
+        const accounts = await DeriveMnemonicKeys(currentResult.mnemonic, currentPassphrase, derivePathInput.value, start, count, '')
+
+        derivedAccountList.innerHTML = ''
+        accounts.forEach(account => {
+            const item = document.createElement('li')
+            const path = document.createElement('span')
+            path.className = 'derived-path'
+            path.textContent = account.path
+            const address = document.createElement('span')
+            address.className = 'derived-address'
+            address.textContent = account.address
+            item.appendChild(path)
+            item.appendChild(address)
+            derivedAccountList.appendChild(item)
+        })
+    } catch (error) {
+        console.error('계정 조회 오류:', error)
+        alert('계정 조회 중 오류가 발생했습니다: ' + error)
+    } finally {
+        deriveBtn.disabled = false
+    }
+}
+
+// SLIP-39 그룹 입력 파싱 (예: "1/1, 2/3, 3/5")
+function parseSLIP39Groups(value) {
+    return value.split(',').map(part => {
+        const match = part.trim().match(/^(\d+)\s*\/\s*(\d+)$/)
+        if (!match) {
+            throw new Error(`그룹 형식이 올바르지 않습니다: ${part.trim()}`)
+        }
+        return { threshold: parseInt(match[1]), count: parseInt(match[2]) }
+    })
+}
+
+// SLIP-39 공유 키 생성
+async function handleGenerateSLIP39() {
+    try {
+        const groups = parseSLIP39Groups(slip39GroupsInput.value)
+        const groupThreshold = parseInt(slip39GroupThresholdInput.value)
+
+        slip39GenerateBtn.disabled = true
+        slip39GenerateBtn.textContent = '생성 중...'
+
+        const result = await GenerateSLIP39Shares(parseInt(slip39StrengthSelect.value), slip39PassphraseInput.value, groupThreshold, groups)
+
+        slip39ShareList.innerHTML = ''
+        const summary = document.createElement('small')
+        summary.textContent = `주소: ${result.address} · ${result.groups.length}개 그룹 중 ${result.groupThreshold}개 필요`
+        slip39ShareList.appendChild(summary)
+
+        result.groups.forEach((group, groupIndex) => {
+            group.forEach((mnemonic, memberIndex) => {
+                const shareDiv = document.createElement('div')
+                shareDiv.className = 'share-item-compact'
+
+                const header = document.createElement('div')
+                header.className = 'share-item-header'
+                const title = document.createElement('span')
+                title.className = 'share-index'
+                title.textContent = `그룹 ${groupIndex + 1} (${groups[groupIndex].threshold}/${groups[groupIndex].count}) · 공유 키 ${memberIndex + 1}`
+                const copyButton = document.createElement('button')
+                copyButton.className = 'copy-btn'
+                copyButton.textContent = '복사'
+                copyButton.addEventListener('click', async () => {
+                    await navigator.clipboard.writeText(mnemonic)
+                    showNotification('클립보드에 복사되었습니다!')
+                })
+                header.appendChild(title)
+                header.appendChild(copyButton)
+
+                const words = document.createElement('pre')
+                words.textContent = mnemonic
+
+                shareDiv.appendChild(header)
+                shareDiv.appendChild(words)
+                slip39ShareList.appendChild(shareDiv)
+            })
+        })
+    } catch (error) {
+        console.error('SLIP-39 생성 오류:', error)
+        alert('SLIP-39 공유 키 생성 중 오류가 발생했습니다: ' + (error.message || error))
+    } finally {
+        slip39GenerateBtn.disabled = false
+        slip39GenerateBtn.textContent = 'SLIP-39 공유 키 생성'
+    }
+}
+
+// SLIP-39 공유 키로 복구
+async function handleRecoverSLIP39() {
+    const mnemonics = slip39RecoverySharesInput.value.split('\n').map(line => line.trim()).filter(line => line !== '')
+    const password = slip39RecoveryPasswordInput.value.trim()
+
+    const strengthValidation = validatePasswordStrength(password)
+    if (!strengthValidation.isValid) {
+        alert(`비밀번호가 요구사항을 충족하지 않습니다:\n${strengthValidation.errors.join('\n')}`)
+        return
+    }
+
+    try {
+        slip39RecoverBtn.disabled = true
+        slip39RecoverBtn.textContent = '복구 중...'
+
+        const result = await RecoverSLIP39Shares(mnemonics, slip39RecoveryPassphraseInput.value, password)
+        sharesTab.style.display = 'none'
+        displayResults(result, false)
+        switchTab('keystore')
+        showNotification('SLIP-39 공유 키로 키스토어가 복구되었습니다!')
+    } catch (error) {
+        console.error('SLIP-39 복구 오류:', error)
+        alert('SLIP-39 복구 중 오류가 발생했습니다: ' + (error.message || error))
+    } finally {
+        slip39RecoverBtn.disabled = false
+        slip39RecoverBtn.textContent = 'SLIP-39 복구'
+    }
+}
+
+// 키스토어 키 유도 강도 변경
+async function handleKDFProfileChange() {
+    try {
+        const kdf = await SetKDFProfile(kdfProfileSelect.value)
+        showNotification(`키 유도 설정: ${kdf.description}`)
+    } catch (error) {
+        console.error('키 유도 설정 오류:', error)
+        alert('키 유도 설정 중 오류가 발생했습니다: ' + (error.message || error))
+        kdfProfileSelect.value = 'standard'
+        await SetKDFProfile('standard')
+    }
+}
+
+// 키스토어 비밀번호 변경
+async function handleChangeKeystorePassword() {
+    const file = changeKeystoreFileInput.files[0]
+    if (!file) {
+        alert('키스토어 파일을 선택해주세요.')
+        return
+    }
+
+    const newPassword = changeNewPasswordInput.value.trim()
+    if (newPassword !== changeConfirmPasswordInput.value.trim()) {
+        alert('새 비밀번호가 일치하지 않습니다.')
+        return
+    }
+    const strengthValidation = validatePasswordStrength(newPassword)
+    if (!strengthValidation.isValid) {
+        alert(`비밀번호가 요구사항을 충족하지 않습니다:\n${strengthValidation.errors.join('\n')}`)
+        return
+    }
+
+    try {
+        changePasswordBtn.disabled = true
+        changePasswordBtn.textContent = '변경 중...'
+
+        const keystoreJSON = await file.text()
+        const result = await ChangeKeystorePassword(keystoreJSON, changeOldPasswordInput.value, newPassword, changeKdfProfileSelect.value)
+        await downloadFile(result.keystore, `${result.address}_keystore.json`)
+
+        changeOldPasswordInput.value = ''
+        changeNewPasswordInput.value = ''
+        changeConfirmPasswordInput.value = ''
+        showNotification(`키스토어 비밀번호가 변경되었습니다! (${result.kdf.description})`)
+    } catch (error) {
+        console.error('비밀번호 변경 오류:', error)
+        alert('비밀번호 변경 중 오류가 발생했습니다: ' + (error.message || error))
+    } finally {
+        changePasswordBtn.disabled = false
+        changePasswordBtn.textContent = '비밀번호 변경'
+    }
+}
+
 // 결과 표시
 function displayResults(result, isShamir = false) {
     currentResult = result
@@ -258,11 +608,27 @@ function displayResults(result, isShamir = false) {
     
     // 기본 정보 표시
     keystoreContent.textContent = result.keystore
+    keystoreKdfContent.textContent = result.kdf ? `키 유도: ${result.kdf.description}` : ''
     publicKeyContent.textContent = result.publicKey
     addressContent.textContent = result.address
+
+    // 니모닉 또는 시드 결과일 때만 니모닉 탭 표시
+    if (result.mnemonic || result.seed) {
+        mnemonicContent.textContent = result.mnemonic || result.seed
+        mnemonicPathContent.textContent = result.mnemonic ? `파생 경로: ${result.path}` : `BIP-39 시드 · 파생 경로: ${result.path}`
+        mnemonicTab.style.display = 'block'
+        deriveSection.style.display = result.mnemonic ? 'block' : 'none'
+        derivedAccountList.innerHTML = ''
+    } else {
+        mnemonicContent.textContent = ''
+        mnemonicPathContent.textContent = ''
+        mnemonicTab.style.display = 'none'
+    }
     
     // 개인키는 노출 버튼 클릭 시에만 표시되도록 설정
     privateKeyText.textContent = ''
+    revealKeystorePasswordInput.value = ''
+    revealPasswordGroup.style.display = isImportedKeystore(result) ? 'block' : 'none'
     const warningElement = document.querySelector('.private-key-warning')
     const contentElement = document.getElementById('privateKeyContent')
     
@@ -310,34 +676,53 @@ function displayResults(result, isShamir = false) {
 
     // 샤미르 쉐어 표시 (개별 다운로드 UI)
     if (isShamir && result.shares) {
-        // Share key를 combine하여 복구된 주소 계산
-        combineAndDisplayRecoveredAddress(result.shares, result.address)
+        // Share key를 combine하여 복구된 주소 계산 (추가 발급된 공유 키만으로는 복구할 수 없음)
+        // 그룹 정책 공유 키는 그룹별로 결합해야 하므로 Go API에서 생성 시 검증
+        const policy = result.shareSet.policy
+        if (!policy && result.shares.length >= result.shareSet.threshold) {
+            combineAndDisplayRecoveredAddress(result.shares, result.address)
+        } else if (recoveredAddressElement) {
+            recoveredAddressElement.style.display = 'none'
+        }
         
         shareListElement.innerHTML = ''
         
         // Threshold 정보 표시
-        const threshold = parseInt(thresholdInput.value)
+        const { threshold, total, setId } = result.shareSet
         const thresholdInfo = document.createElement('div')
         thresholdInfo.className = 'threshold-info'
         thresholdInfo.innerHTML = `
             <div class="threshold-details">
-                <strong>설정 정보:</strong> 총 ${result.shares.length}개 중 ${threshold}개로 복구 가능
+                <strong>설정 정보:</strong> 총 ${total}개 중 ${threshold}개로 복구 가능
+            </div>
+            <div class="threshold-details">
+                <strong>공유 키 세트 ID:</strong> ${setId}
             </div>
+            ${result.shareSet.commitments ? `
+            <div class="threshold-details">
+                <strong>검증 가능:</strong> 커밋먼트 ${result.shareSet.commitments.length}개가 각 공유 키에 저장됩니다
+            </div>` : ''}
+            ${policy ? `
+            <div class="threshold-details">
+                <strong>그룹 정책:</strong> ${policy.description}
+            </div>` : ''}
         `
         shareListElement.appendChild(thresholdInfo)
         
         result.shares.forEach((share, index) => {
+            const shareIndex = result.indexes[index]
+            const groupName = policy ? ` (${policy.groups[result.groups[index] - 1].name})` : ''
             const shareDiv = document.createElement('div')
             shareDiv.className = 'share-item-compact'
             shareDiv.innerHTML = `
                 <div class="share-item-header">
-                    <span class="share-index">공유 키 ${index + 1}</span>
+                    <span class="share-index">공유 키 ${shareIndex}${groupName}</span>
                     <span class="share-status">개별 암호화된 keystore로 다운로드</span>
                 </div>
                 <div class="share-password-inputs">
                     <div class="share-password-group">
                         <label for="sharePassword${index}">비밀번호:</label>
-                        <input type="password" id="sharePassword${index}" placeholder="공유 키 ${index + 1} 비밀번호 입력">
+                        <input type="password" id="sharePassword${index}" placeholder="공유 키 ${shareIndex} 비밀번호 입력">
                     </div>
                     <div class="share-password-group">
                         <label for="shareConfirmPassword${index}">비밀번호 확인:</label>
@@ -346,6 +731,17 @@ function displayResults(result, isShamir = false) {
                     </div>
                     <button id="downloadShareBtn${index}" class="share-download-btn" disabled data-share-index="${index}">다운로드</button>
                 </div>
+                <div class="share-password-inputs">
+                    <div class="share-password-group">
+                        <label for="shareRecipient${index}">또는 보관자 공개키:</label>
+                        <input type="text" id="shareRecipient${index}" placeholder="secp256k1 공개키 (04... 또는 02/03...) 또는 X25519 공개키 (32바이트 hex)">
+                    </div>
+                    <button id="encryptShareBtn${index}" class="share-download-btn">공개키로 암호화</button>
+                </div>
+                <div class="share-words-section" style="display: ${policy ? 'none' : 'block'};">
+                    <button id="shareWordsBtn${index}" class="share-words-btn">단어 보기</button>
+                    <div id="shareWords${index}" class="share-words" style="display: none;"></div>
+                </div>
             `
             shareListElement.appendChild(shareDiv)
             
@@ -361,6 +757,10 @@ function displayResults(result, isShamir = false) {
             
             // 다운로드 버튼 이벤트 리스너
             downloadBtn.addEventListener('click', () => handleDownloadShare(index))
+            document.getElementById(`encryptShareBtn${index}`).addEventListener('click', () => handleDownloadCustodianShare(index))
+
+            // 단어 보기 버튼 이벤트 리스너
+            document.getElementById(`shareWordsBtn${index}`).addEventListener('click', () => toggleShareWords(index))
         })
     }
 
@@ -371,11 +771,34 @@ function displayResults(result, isShamir = false) {
     resultsSection.scrollIntoView({ behavior: 'smooth' })
 }
 
+// 공유 키를 종이 백업용 단어 목록으로 표시/숨김
+function toggleShareWords(index) {
+    const wordsElement = document.getElementById(`shareWords${index}`)
+    const wordsBtn = document.getElementById(`shareWordsBtn${index}`)
+
+    if (wordsElement.style.display !== 'none') {
+        wordsElement.style.display = 'none'
+        wordsElement.textContent = ''
+        wordsBtn.textContent = '단어 보기'
+        return
+    }
+
+    if (!confirm('공유 키 단어는 암호화되지 않습니다. 주변에 보는 사람이 없는지 확인하세요. 표시하시겠습니까?')) {
+        return
+    }
+
+    const words = currentResult.shareWords[index].split(' ')
+    wordsElement.innerHTML = words
+        .map((word, i) => `<span class="share-word"><small>${i + 1}</small> ${word}</span>`)
+        .join('')
+    wordsElement.style.display = 'grid'
+    wordsBtn.textContent = '단어 숨기기'
+}
+
 // Share key를 combine하여 복구된 주소 표시
 async function combineAndDisplayRecoveredAddress(shares, originalAddress) {
     try {
-        const recoveredResult = await CombineShamirShares(shares)
-        const recoveredAddress = recoveredResult.address
+        const recoveredAddress = await VerifyShamirShares(shares, currentPassphrase)
         
         if (recoveredAddressElement) {
             const isMatch = recoveredAddress.toLowerCase() === originalAddress.toLowerCase()
@@ -464,6 +887,9 @@ async function handleCopy() {
         case 'address':
             textToCopy = currentResult.address
             break
+        case 'mnemonic':
+            textToCopy = currentResult.mnemonic || currentResult.seed
+            break
     }
 
     try {
@@ -510,6 +936,10 @@ function handleDownload() {
             content = currentResult.address
             filename = `${addressWithoutPrefix}_address.txt`
             break
+        case 'mnemonic':
+            content = currentResult.mnemonic || currentResult.seed
+            filename = `${addressWithoutPrefix}_mnemonic.txt`
+            break
     }
 
     if (content && filename) {
@@ -619,33 +1049,325 @@ async function handleDownloadShare(index) {
         return
     }
 
+    const shareIndex = currentResult.indexes[index]
     try {
         const shareKeystore = await CreateShareKeystore(
             currentResult.shares[index], 
             password, 
-            index + 1, 
-            currentResult.address
+            shareIndex, 
+            currentResult.shareSet
         )
         
         const addressWithoutPrefix = currentResult.address.replace('0x', '')
-        const filename = `${addressWithoutPrefix}_sharekey_${index + 1}.json`
+        const filename = `${addressWithoutPrefix}_sharekey_${shareIndex}.json`
+        await downloadFile(shareKeystore.keystore, filename)
+
+        showNotification(`공유 키 ${shareIndex} 키스토어가 다운로드되었습니다! (${shareKeystore.kdf.description})`)
+
+    } catch (error) {
+        console.error(`공유 키 ${shareIndex} 다운로드 실패:`, error)
+        alert(`공유 키 ${shareIndex} 다운로드에 실패했습니다: ${error.message}`)
+    }
+}
+
+// 공유 키를 보관자의 공개키(secp256k1 ECIES 또는 X25519)로 암호화하여 다운로드 (비밀번호 불필요)
+// 보관자는 복구 화면에서 자신의 키스토어 또는 X25519 개인키로 공유 키를 열 수 있음
+async function handleDownloadCustodianShare(index) {
+    const recipient = document.getElementById(`shareRecipient${index}`).value.trim()
+    if (!recipient) {
+        alert('보관자 공개키를 입력해주세요.')
+        return
+    }
+
+    const shareIndex = currentResult.indexes[index]
+    try {
+        const shareKeystore = await CreateCustodianShare(currentResult.shares[index], recipient, shareIndex, currentResult.shareSet)
+
+        const addressWithoutPrefix = currentResult.address.replace('0x', '')
+        const filename = `${addressWithoutPrefix}_sharekey_${shareIndex}.json`
         await downloadFile(shareKeystore.keystore, filename)
 
-        showNotification(`공유 키 ${index + 1} 키스토어가 다운로드되었습니다!`)
+        showNotification(`공유 키 ${shareIndex}이(가) 보관자 공개키로 암호화되어 다운로드되었습니다!`)
+    } catch (error) {
+        console.error(`공유 키 ${shareIndex} 암호화 실패:`, error)
+        alert(`공유 키 ${shareIndex} 암호화에 실패했습니다: ${error.message || error}`)
+    }
+}
+
+// 보관자 공개키로 암호화된 공유 키의 키 종류 확인 ('secp256k1', 'x25519' 또는 false)
+function custodianKeyType(content) {
+    try {
+        switch (JSON.parse(content).crypto?.cipher) {
+            case 'ecies-secp256k1':
+                return 'secp256k1'
+            case 'x25519-hkdf-sha256-aes-256-gcm':
+                return 'x25519'
+            default:
+                return false
+        }
+    } catch {
+        return false
+    }
+}
+
+// 보관자 공유 키를 보관자 키스토어 또는 X25519 개인키로 열어 새 비밀번호의 공유 키로 변환 (메모리에서만 사용)
+async function handleUnwrapShare(index) {
+    const x25519 = recoveryShareFiles[index].custodian === 'x25519'
+    const sharePassword = document.getElementById(`recoverySharePassword${index}`).value
+    const statusElement = document.getElementById(`verifyShareStatus${index}`)
 
+    const keystoreFile = x25519 ? null : document.getElementById(`custodianKeystore${index}`).files[0]
+    const privateKeyInput = x25519 ? document.getElementById(`custodianX25519Key${index}`) : null
+    if (x25519 ? !privateKeyInput.value.trim() : !keystoreFile) {
+        alert(x25519 ? '보관자 X25519 개인키를 입력해주세요.' : '보관자 키스토어 파일을 선택해주세요.')
+        return
+    }
+    const strengthValidation = validatePasswordStrength(sharePassword)
+    if (!strengthValidation.isValid) {
+        alert(`새 공유 키 비밀번호가 요구사항을 충족하지 않습니다:\n${strengthValidation.errors.join('\n')}`)
+        return
+    }
+
+    try {
+        const result = x25519
+            ? await UnwrapX25519Share(recoveryShareFiles[index].content, privateKeyInput.value, sharePassword)
+            : await UnwrapCustodianShare(recoveryShareFiles[index].content, await keystoreFile.text(), document.getElementById(`custodianPassword${index}`).value, sharePassword)
+        if (x25519) {
+            privateKeyInput.value = ''
+        }
+        recoveryShareFiles[index] = { ...recoveryShareFiles[index], content: result.keystore, custodian: false }
+        document.getElementById(`unwrapShareBtn${index}`).disabled = true
+        statusElement.textContent = `✓ 공유 키 ${result.index}을(를) 열었습니다`
+        statusElement.className = 'share-password-match match'
     } catch (error) {
-        console.error(`공유 키 ${index + 1} 다운로드 실패:`, error)
-        alert(`공유 키 ${index + 1} 다운로드에 실패했습니다: ${error.message}`)
+        statusElement.textContent = `✗ 열기 실패: ${error.message || error}`
+        statusElement.className = 'share-password-match mismatch'
+    }
+    updateRecoverButton()
+}
+
+// 검증 가능한 공유 키를 다른 공유 키 없이 커밋먼트로 검증
+async function handleVerifyShare(index) {
+    const password = document.getElementById(`recoverySharePassword${index}`).value
+    const statusElement = document.getElementById(`verifyShareStatus${index}`)
+
+    try {
+        const result = await VerifyShareKeystore(recoveryShareFiles[index].content, password)
+        statusElement.textContent = `✓ 공유 키 ${result.shareIndex}이(가) 커밋먼트와 일치합니다 (${result.address})`
+        statusElement.className = 'share-password-match match'
+    } catch (error) {
+        statusElement.textContent = `✗ 검증 실패: ${error.message || error}`
+        statusElement.className = 'share-password-match mismatch'
+    }
+}
+
+// 공유 키 파일 선택 처리
+async function handleShareFilesSelected() {
+    recoveryShareFiles = []
+    recoveryShareListElement.innerHTML = ''
+
+    const files = Array.from(shareFilesInput.files)
+    for (const [index, file] of files.entries()) {
+        const content = await file.text()
+        const custodian = custodianKeyType(content)
+        recoveryShareFiles.push({ name: file.name, content, custodian })
+
+        const shareDiv = document.createElement('div')
+        shareDiv.className = 'share-item-compact'
+        shareDiv.innerHTML = `
+            <div class="share-item-header">
+                <span class="share-index">${file.name}</span>
+            </div>
+            ${custodian === 'x25519' ? `
+            <div class="share-password-inputs">
+                <div class="share-password-group">
+                    <label for="custodianX25519Key${index}">보관자 X25519 개인키:</label>
+                    <input type="password" id="custodianX25519Key${index}" placeholder="32바이트 hex 개인키 입력">
+                </div>
+            </div>` : ''}
+            ${custodian === 'secp256k1' ? `
+            <div class="share-password-inputs">
+                <div class="share-password-group">
+                    <label for="custodianKeystore${index}">보관자 키스토어:</label>
+                    <input type="file" id="custodianKeystore${index}" accept=".json">
+                </div>
+                <div class="share-password-group">
+                    <label for="custodianPassword${index}">보관자 키스토어 비밀번호:</label>
+                    <input type="password" id="custodianPassword${index}" placeholder="보관자 키스토어 비밀번호 입력">
+                </div>
+            </div>` : ''}
+            <div class="share-password-inputs">
+                <div class="share-password-group">
+                    <label for="recoverySharePassword${index}">${custodian ? '새 공유 키 비밀번호:' : '비밀번호:'}</label>
+                    <input type="password" id="recoverySharePassword${index}" placeholder="공유 키 비밀번호 입력">
+                </div>
+                ${custodian
+                    ? `<button id="unwrapShareBtn${index}" class="share-words-btn">보관자 키로 열기</button>`
+                    : `<button id="verifyShareBtn${index}" class="share-words-btn">검증</button>`}
+            </div>
+            <small id="verifyShareStatus${index}" class="share-password-match">${custodian ? `보관자 공개키로 암호화된 공유 키입니다. 보관자 ${custodian === 'x25519' ? 'X25519 개인키' : '키스토어'}로 연 뒤 복구할 수 있습니다` : ''}</small>
+        `
+        recoveryShareListElement.appendChild(shareDiv)
+        document.getElementById(`recoverySharePassword${index}`).addEventListener('input', updateRecoverButton)
+        if (custodian) {
+            document.getElementById(`unwrapShareBtn${index}`).addEventListener('click', () => handleUnwrapShare(index))
+        } else {
+            document.getElementById(`verifyShareBtn${index}`).addEventListener('click', () => handleVerifyShare(index))
+        }
     }
+
+    updateRecoverButton()
+}
+
+// 복구 버튼 활성화 상태 갱신
+function updateRecoverButton() {
+    // 보관자 공유 키는 먼저 보관자 키스토어로 열어야 함
+    const sharePasswordsFilled = recoveryShareFiles.every((file, index) =>
+        !file.custodian && document.getElementById(`recoverySharePassword${index}`).value !== ''
+    )
+
+    recoverBtn.disabled = recoveryShareFiles.length < 2 ||
+        !sharePasswordsFilled ||
+        !recoveryPasswordInput.value ||
+        recoveryPasswordInput.value !== recoveryConfirmPasswordInput.value
+
+    // 재분할은 새 키스토어 비밀번호 없이 공유 키 비밀번호만 필요
+    reshareBtn.disabled = recoveryShareFiles.length < 2 || !sharePasswordsFilled
+    extendBtn.disabled = reshareBtn.disabled
+}
+
+// 공유 키를 새 공유 키 세트로 재분할 (개인키는 결과에 포함되지 않음)
+async function handleReshare() {
+    const totalShares = parseInt(reshareTotalSharesInput.value) || 0
+    const threshold = parseInt(reshareThresholdInput.value) || 0
+
+    const keystores = recoveryShareFiles.map(file => file.content)
+    const passwords = recoveryShareFiles.map((_, index) =>
+        document.getElementById(`recoverySharePassword${index}`).value
+    )
+
+    try {
+        reshareBtn.disabled = true
+        reshareBtn.textContent = '재분할 중...'
+
+        const passphrase = recoveryPassphraseInput.value
+        const result = await ReshareShareKeystores(keystores, passwords, passphrase, totalShares, threshold)
+        currentPassphrase = passphrase
+        sharesTab.style.display = 'block'
+        displayResults(result, true)
+        showNotification('새 공유 키 세트가 생성되었습니다. 각 공유 키를 다운로드하세요!')
+
+    } catch (error) {
+        console.error('공유 키 재분할 오류:', error)
+        alert('공유 키 재분할 중 오류가 발생했습니다: ' + (error.message || error))
+    } finally {
+        reshareBtn.textContent = '재분할'
+        updateRecoverButton()
+    }
+}
+
+// 기존 공유 키 세트에 새 보관자용 공유 키 추가 (기존 공유 키는 그대로 유효)
+async function handleExtendShares() {
+    const count = parseInt(extendCountInput.value) || 0
+
+    const keystores = recoveryShareFiles.map(file => file.content)
+    const passwords = recoveryShareFiles.map((_, index) =>
+        document.getElementById(`recoverySharePassword${index}`).value
+    )
+
+    try {
+        extendBtn.disabled = true
+        extendBtn.textContent = '추가 중...'
+
+        const passphrase = recoveryPassphraseInput.value
+        const result = await ExtendShareKeystores(keystores, passwords, passphrase, count)
+        currentPassphrase = passphrase
+        sharesTab.style.display = 'block'
+        displayResults(result, true)
+        showNotification('새 공유 키가 발급되었습니다. 각 공유 키를 다운로드하세요!')
+
+    } catch (error) {
+        console.error('공유 키 추가 오류:', error)
+        alert('공유 키 추가 중 오류가 발생했습니다: ' + (error.message || error))
+    } finally {
+        extendBtn.textContent = '공유 키 추가'
+        updateRecoverButton()
+    }
+}
+
+// 공유 키 파일로 키 복구
+async function handleRecover() {
+    const newPassword = recoveryPasswordInput.value.trim()
+
+    const strengthValidation = validatePasswordStrength(newPassword)
+    if (!strengthValidation.isValid) {
+        alert(`비밀번호가 요구사항을 충족하지 않습니다:\n${strengthValidation.errors.join('\n')}`)
+        return
+    }
+
+    if (newPassword !== recoveryConfirmPasswordInput.value.trim()) {
+        alert('비밀번호가 일치하지 않습니다.')
+        return
+    }
+
+    const keystores = recoveryShareFiles.map(file => file.content)
+    const passwords = recoveryShareFiles.map((_, index) =>
+        document.getElementById(`recoverySharePassword${index}`).value
+    )
+
+    try {
+        recoverBtn.disabled = true
+        recoverBtn.textContent = '복구 중...'
+
+        const passphrase = recoveryPassphraseInput.value
+        const result = await RecoverSecretFromShareKeystores(keystores, passwords, passphrase, newPassword)
+        currentPassphrase = passphrase
+        sharesTab.style.display = 'none'
+        displayResults(result, false)
+        switchTab('keystore')
+        showNotification('공유 키로 키스토어가 복구되었습니다!')
+
+    } catch (error) {
+        console.error('공유 키 복구 오류:', error)
+        alert('공유 키 복구 중 오류가 발생했습니다: ' + (error.message || error))
+    } finally {
+        recoverBtn.textContent = '키 복구'
+        updateRecoverButton()
+    }
+}
+
+// 개인키가 없는 가져온 키스토어 결과인지 확인
+function isImportedKeystore(result) {
+    return !result.privateKey && importedKeystore !== null && importedKeystore.address === result.address
 }
 
 // 개인키 노출 처리
-function handleRevealPrivateKey() {
+async function handleRevealPrivateKey() {
     console.log('개인키 노출 버튼 클릭됨')
     if (!currentResult) {
         console.log('currentResult가 없음')
         return
     }
+    // 가져온 키스토어의 개인키는 요청할 때만 다시 입력받은 비밀번호로 복호화
+    if (isImportedKeystore(currentResult)) {
+        const password = revealKeystorePasswordInput.value
+        revealKeystorePasswordInput.value = ''
+        if (!password) {
+            alert('키스토어 비밀번호를 입력해주세요.')
+            return
+        }
+        try {
+            currentResult.privateKey = await RevealKeystorePrivateKey(importedKeystore.keystore, password)
+        } catch (error) {
+            alert('개인키를 복호화하지 못했습니다: ' + (error.message || error))
+            return
+        }
+        revealPasswordGroup.style.display = 'none'
+    }
+    if (!currentResult.privateKey) {
+        alert('이 결과에는 개인키가 포함되지 않습니다.')
+        return
+    }
     
     console.log('개인키 노출 확인 대화상자 표시')
     
@@ -830,6 +1552,26 @@ document.addEventListener('DOMContentLoaded', () => {
     updateUIForShareCount()
 })
 
+// 키 유형에 따라 니모닉 입력 필드 표시
+function updateUIForKeyType() {
+    const keyType = keyTypeSelect.value
+    mnemonicImportGroup.style.display = keyType === 'importMnemonic' ? 'block' : 'none'
+    passphraseGroup.style.display = ['random', 'importKeystore', 'importPrivateKey'].includes(keyType) ? 'none' : 'block'
+    keystoreImportGroup.style.display = keyType === 'importKeystore' ? 'block' : 'none'
+    privateKeyImportGroup.style.display = keyType === 'importPrivateKey' ? 'block' : 'none'
+
+    // 분할 대상은 공유 키 모드에서 니모닉을 분할할 때만 선택
+    const isShareMode = (parseInt(totalSharesInput.value) || 1) > 1
+    splitSecretGroup.style.display = isShareMode && !['random', 'importKeystore', 'importPrivateKey'].includes(keyType) ? 'block' : 'none'
+
+    // 검증 가능한 공유 키는 랜덤 개인키만 지원
+    verifiableGroup.style.display = isShareMode && keyType === 'random' ? 'block' : 'none'
+
+    // 그룹 정책도 랜덤 개인키만 지원
+    policyGroup.style.display = isShareMode && keyType === 'random' ? 'block' : 'none'
+    policyInputs.style.display = usePolicyInput.checked ? 'block' : 'none'
+}
+
 // 총 개수에 따라 UI 업데이트
 function updateUIForShareCount() {
     const totalShares = parseInt(totalSharesInput.value) || 1
@@ -850,6 +1592,10 @@ function updateUIForShareCount() {
         // 비밀번호 입력 필드 표시
         passwordInput.parentElement.style.display = 'block'
         confirmPasswordInput.parentElement.style.display = 'block'
+
+        // 키 유형 선택 표시
+        keyTypeGroup.style.display = 'block'
+        updateUIForKeyType()
         
         // 공유 키 탭 숨기기
         sharesTab.style.display = 'none'
@@ -867,6 +1613,10 @@ function updateUIForShareCount() {
         // 비밀번호 입력 필드 숨기기
         passwordInput.parentElement.style.display = 'none'
         confirmPasswordInput.parentElement.style.display = 'none'
+
+        // 키 유형 선택 표시 (니모닉은 엔트로피 또는 시드로 분할)
+        keyTypeGroup.style.display = 'block'
+        updateUIForKeyType()
         
         // 공유 키 탭 표시
         sharesTab.style.display = 'block'
@@ -0,0 +1,143 @@
+package keygen
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DefaultPath is the BIP-44 path of the first Ethereum account, m/44'/60'/0'/0/0
+var DefaultPath = accounts.DefaultBaseDerivationPath
+
+// MaxDeriveCount limits how many accounts DeriveRange derives in one call
+const MaxDeriveCount = 1000
+
+var (
+	// ErrInvalidDerivation is returned in the rare case that BIP-32 derivation
+	// produces an invalid key for a seed and path
+	ErrInvalidDerivation = errors.New("invalid BIP-32 derivation")
+
+	// ErrInvalidPath is returned for derivation paths that cannot be parsed
+	ErrInvalidPath = errors.New("invalid derivation path")
+
+	// ErrInvalidRange is returned for account ranges outside what DeriveRange supports
+	ErrInvalidRange = errors.New("invalid account range")
+)
+
+// DerivedKey is a key together with the path it was derived at
+type DerivedKey struct {
+	Path accounts.DerivationPath
+	*Key
+}
+
+// ParsePath parses a derivation path such as m/44'/60'/0'/0/0. Relative paths
+// are appended to the default root m/44'/60'/0'/0.
+func ParsePath(path string) (accounts.DerivationPath, error) {
+	parsed, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
+	return parsed, nil
+}
+
+// DeriveRange derives count accounts starting at index start. The last
+// component of base is replaced by start, start+1, ... and keeps its
+// hardened flag, so m/44'/60'/0'/0/0 yields m/44'/60'/0'/0/i.
+func DeriveRange(seed []byte, base accounts.DerivationPath, start int, count int) ([]DerivedKey, error) {
+	if len(base) == 0 {
+		return nil, fmt.Errorf("%w: empty path", ErrInvalidPath)
+	}
+	if count < 1 || count > MaxDeriveCount {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidRange, MaxDeriveCount)
+	}
+	if start < 0 || start+count > hardenedOffset {
+		return nil, fmt.Errorf("%w: indexes must be between 0 and %d", ErrInvalidRange, hardenedOffset-1)
+	}
+
+	last := len(base) - 1
+	hardened := base[last] & hardenedOffset
+
+	keys := make([]DerivedKey, count)
+	for i := range keys {
+		path := append(accounts.DerivationPath{}, base...)
+		path[last] = hardened | uint32(start+i)
+
+		key, err := DeriveKey(seed, path)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = DerivedKey{Path: path, Key: key}
+	}
+
+	return keys, nil
+}
+
+// hardenedOffset is added to a path component to select hardened derivation
+const hardenedOffset = 0x80000000
+
+// DeriveKey derives the key at a BIP-32 path from a seed
+func DeriveKey(seed []byte, path accounts.DerivationPath) (*Key, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key, chainCode := sum[:32], sum[32:]
+	if !validScalar(new(big.Int).SetBytes(key)) {
+		return nil, fmt.Errorf("%w: master key out of range", ErrInvalidDerivation)
+	}
+
+	for depth, index := range path {
+		var err error
+		key, chainCode, err = deriveChild(key, chainCode, index)
+		if err != nil {
+			return nil, fmt.Errorf("%w at %s", err, path[:depth+1])
+		}
+	}
+
+	return FromBytes(key)
+}
+
+// deriveChild performs one step of BIP-32 private parent to private child derivation
+func deriveChild(key []byte, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	data := make([]byte, 0, 37)
+	if index >= hardenedOffset {
+		data = append(data, 0)
+		data = append(data, key...)
+	} else {
+		privateKey, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidDerivation, err)
+		}
+		data = append(data, crypto.CompressPubkey(&privateKey.PublicKey)...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, nil, fmt.Errorf("%w: child tweak out of range", ErrInvalidDerivation)
+	}
+
+	child := tweak.Add(tweak, new(big.Int).SetBytes(key))
+	child.Mod(child, crypto.S256().Params().N)
+	if !validScalar(child) {
+		return nil, nil, fmt.Errorf("%w: child key is zero", ErrInvalidDerivation)
+	}
+
+	return child.FillBytes(make([]byte, 32)), sum[32:], nil
+}
+
+// validScalar reports whether k is a valid secp256k1 private key scalar
+func validScalar(k *big.Int) bool {
+	return k.Sign() > 0 && k.Cmp(crypto.S256().Params().N) < 0
+}
@@ -0,0 +1,75 @@
+// Package keygen generates and loads secp256k1 keys for EVM accounts.
+package keygen
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrInvalidPrivateKey is returned when bytes do not form a valid secp256k1 private key
+var ErrInvalidPrivateKey = errors.New("invalid private key")
+
+// Key is a secp256k1 key pair together with its EVM address
+type Key struct {
+	PrivateKey *ecdsa.PrivateKey
+	Address    common.Address
+}
+
+// Generate creates a new random key
+func Generate() (*Key, error) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	return FromECDSA(privateKey), nil
+}
+
+// FromECDSA wraps an existing private key
+func FromECDSA(privateKey *ecdsa.PrivateKey) *Key {
+	return &Key{
+		PrivateKey: privateKey,
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+	}
+}
+
+// FromBytes loads a key from its 32-byte private key encoding
+func FromBytes(b []byte) (*Key, error) {
+	privateKey, err := crypto.ToECDSA(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+
+	return FromECDSA(privateKey), nil
+}
+
+// FromHex loads a key from its hex private key encoding, with or without 0x prefix
+func FromHex(privateKeyHex string) (*Key, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(privateKeyHex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+
+	return FromBytes(b)
+}
+
+// Bytes returns the 32-byte private key encoding
+func (k *Key) Bytes() []byte {
+	return crypto.FromECDSA(k.PrivateKey)
+}
+
+// PrivateKeyHex returns the private key as hex without 0x prefix
+func (k *Key) PrivateKeyHex() string {
+	return hex.EncodeToString(k.Bytes())
+}
+
+// PublicKeyHex returns the uncompressed public key as hex without 0x prefix
+func (k *Key) PublicKeyHex() string {
+	return hex.EncodeToString(crypto.FromECDSAPub(&k.PrivateKey.PublicKey))
+}
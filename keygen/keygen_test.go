@@ -0,0 +1,49 @@
+package keygen
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFromBytes(t *testing.T) {
+	key, err := Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	loaded, err := FromBytes(key.Bytes())
+	if err != nil {
+		t.Fatal("Failed to load key:", err)
+	}
+	if loaded.Address != key.Address || !bytes.Equal(loaded.Bytes(), key.Bytes()) {
+		t.Errorf("Loaded key %s does not match generated key %s", loaded.Address.Hex(), key.Address.Hex())
+	}
+
+	// Zero is not a valid secp256k1 scalar
+	if _, err := FromBytes(make([]byte, 32)); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("Expected ErrInvalidPrivateKey, got %v", err)
+	}
+}
+
+func TestFromHex(t *testing.T) {
+	key, err := Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	for _, privateKeyHex := range []string{key.PrivateKeyHex(), "0x" + key.PrivateKeyHex(), " " + key.PrivateKeyHex() + "\n"} {
+		loaded, err := FromHex(privateKeyHex)
+		if err != nil {
+			t.Errorf("Failed to load key from %q: %v", privateKeyHex, err)
+		} else if loaded.Address != key.Address {
+			t.Errorf("Loaded key %s does not match generated key %s", loaded.Address.Hex(), key.Address.Hex())
+		}
+	}
+
+	for _, privateKeyHex := range []string{"", "0xzz", key.PrivateKeyHex()[:62]} {
+		if _, err := FromHex(privateKeyHex); !errors.Is(err, ErrInvalidPrivateKey) {
+			t.Errorf("Expected ErrInvalidPrivateKey for %q, got %v", privateKeyHex, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package keygen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrInvalidWordCount is returned for mnemonic lengths other than 12 or 24 words
+	ErrInvalidWordCount = errors.New("mnemonic must have 12 or 24 words")
+
+	// ErrInvalidMnemonic is returned for mnemonics with unknown words or a bad checksum
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+)
+
+// NewMnemonic generates a new English BIP-39 mnemonic of 12 or 24 words
+func NewMnemonic(words int) (string, error) {
+	var entropyBits int
+	switch words {
+	case 12:
+		entropyBits = 128
+	case 24:
+		entropyBits = 256
+	default:
+		return "", ErrInvalidWordCount
+	}
+
+	entropy, err := bip39.NewEntropy(entropyBits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate entropy: %w", err)
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// NormalizeMnemonic lowercases a mnemonic and collapses its whitespace
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(norm.NFKD.String(mnemonic))), " ")
+}
+
+// MnemonicSeed validates a mnemonic and returns its 64-byte BIP-39 seed
+func MnemonicSeed(mnemonic string, passphrase string) ([]byte, error) {
+	mnemonic = NormalizeMnemonic(mnemonic)
+
+	words := len(strings.Fields(mnemonic))
+	if words != 12 && words != 24 {
+		return nil, fmt.Errorf("%w, got %d", ErrInvalidWordCount, words)
+	}
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+
+	return bip39.NewSeed(mnemonic, norm.NFKD.String(passphrase)), nil
+}
+
+// MnemonicEntropy validates a mnemonic and returns the 16 or 32 bytes of
+// entropy it encodes
+func MnemonicEntropy(mnemonic string) ([]byte, error) {
+	mnemonic = NormalizeMnemonic(mnemonic)
+
+	words := len(strings.Fields(mnemonic))
+	if words != 12 && words != 24 {
+		return nil, fmt.Errorf("%w, got %d", ErrInvalidWordCount, words)
+	}
+
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+
+	return entropy, nil
+}
+
+// MnemonicFromEntropy encodes 16 or 32 bytes of entropy as a 12 or 24 word mnemonic
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	if len(entropy) != 16 && len(entropy) != 32 {
+		return "", fmt.Errorf("%w, got %d bytes of entropy", ErrInvalidWordCount, len(entropy))
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// FromMnemonic derives the key of the first Ethereum account (DefaultPath) of a mnemonic
+func FromMnemonic(mnemonic string, passphrase string) (*Key, error) {
+	seed, err := MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return DeriveKey(seed, DefaultPath)
+}
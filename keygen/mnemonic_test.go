@@ -0,0 +1,163 @@
+package keygen
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+)
+
+func TestMnemonicSeedVector(t *testing.T) {
+	// BIP-39 test vector for all-zero 128-bit entropy
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	seed, err := MnemonicSeed(mnemonic, "TREZOR")
+	if err != nil {
+		t.Fatal("Failed to derive seed:", err)
+	}
+
+	expected := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if hex.EncodeToString(seed) != expected {
+		t.Errorf("Seed %x, expected %s", seed, expected)
+	}
+
+	// Well-known first Ethereum account of the same mnemonic without passphrase
+	key, err := FromMnemonic("  Abandon "+strings.Repeat("abandon ", 10)+"about\n", "")
+	if err != nil {
+		t.Fatal("Failed to derive key:", err)
+	}
+	if key.Address.Hex() != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("Derived address %s, expected 0x9858EfFD232B4033E47d90003D41EC34EcaEda94", key.Address.Hex())
+	}
+}
+
+func TestMnemonicEntropy(t *testing.T) {
+	// BIP-39 test vector for 0x7f repeated 16 times
+	mnemonic := "legal winner thank year wave sausage worth useful legal winner thank yellow"
+	entropy, err := MnemonicEntropy(mnemonic)
+	if err != nil {
+		t.Fatal("Failed to decode mnemonic:", err)
+	}
+	if hex.EncodeToString(entropy) != strings.Repeat("7f", 16) {
+		t.Errorf("Entropy %x, expected %s", entropy, strings.Repeat("7f", 16))
+	}
+
+	encoded, err := MnemonicFromEntropy(entropy)
+	if err != nil {
+		t.Fatal("Failed to encode entropy:", err)
+	}
+	if encoded != mnemonic {
+		t.Errorf("Encoded %q, expected %q", encoded, mnemonic)
+	}
+
+	if _, err := MnemonicEntropy(strings.Repeat("abandon ", 12)); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Errorf("Expected ErrInvalidMnemonic, got %v", err)
+	}
+	if _, err := MnemonicFromEntropy(make([]byte, 20)); !errors.Is(err, ErrInvalidWordCount) {
+		t.Errorf("Expected ErrInvalidWordCount, got %v", err)
+	}
+}
+
+func TestDeriveKeyBIP32Vector(t *testing.T) {
+	// BIP-32 test vector 1
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+
+	for _, tt := range tests {
+		path, err := accounts.ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatal("Failed to parse path:", err)
+		}
+
+		key, err := DeriveKey(seed, path)
+		if err != nil {
+			t.Fatalf("Failed to derive %s: %v", tt.path, err)
+		}
+		if key.PrivateKeyHex() != tt.key {
+			t.Errorf("%s: derived %s, expected %s", tt.path, key.PrivateKeyHex(), tt.key)
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, words := range []int{12, 24} {
+		mnemonic, err := NewMnemonic(words)
+		if err != nil {
+			t.Fatal("Failed to generate mnemonic:", err)
+		}
+		if len(strings.Fields(mnemonic)) != words {
+			t.Errorf("Expected %d words, got %q", words, mnemonic)
+		}
+		if _, err := FromMnemonic(mnemonic, "passphrase"); err != nil {
+			t.Errorf("Generated mnemonic rejected: %v", err)
+		}
+	}
+
+	if _, err := NewMnemonic(15); !errors.Is(err, ErrInvalidWordCount) {
+		t.Errorf("Expected ErrInvalidWordCount, got %v", err)
+	}
+
+	// Last word changed, so the checksum no longer matches
+	if _, err := FromMnemonic(strings.Repeat("abandon ", 12), ""); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Errorf("Expected ErrInvalidMnemonic, got %v", err)
+	}
+}
+
+func TestDeriveRange(t *testing.T) {
+	seed, err := MnemonicSeed(strings.Repeat("abandon ", 11)+"about", "")
+	if err != nil {
+		t.Fatal("Failed to derive seed:", err)
+	}
+
+	keys, err := DeriveRange(seed, DefaultPath, 0, 3)
+	if err != nil {
+		t.Fatal("Failed to derive range:", err)
+	}
+
+	expected := []struct {
+		path    string
+		address string
+	}{
+		{"m/44'/60'/0'/0/0", "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+		{"m/44'/60'/0'/0/1", "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"},
+		{"m/44'/60'/0'/0/2", "0xb6716976A3ebe8D39aCEB04372f22Ff8e6802D7A"},
+	}
+	for i, want := range expected {
+		if keys[i].Path.String() != want.path || keys[i].Address.Hex() != want.address {
+			t.Errorf("Account %d: %s %s, expected %s %s", i, keys[i].Path, keys[i].Address.Hex(), want.path, want.address)
+		}
+	}
+
+	// Custom path with a hardened last component keeps it hardened
+	base, err := ParsePath("m/44'/60'/0'")
+	if err != nil {
+		t.Fatal("Failed to parse path:", err)
+	}
+	keys, err = DeriveRange(seed, base, 5, 2)
+	if err != nil {
+		t.Fatal("Failed to derive range:", err)
+	}
+	if keys[0].Path.String() != "m/44'/60'/5'" || keys[1].Path.String() != "m/44'/60'/6'" {
+		t.Errorf("Unexpected paths %s, %s", keys[0].Path, keys[1].Path)
+	}
+
+	if _, err := ParsePath("m/44'/x"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, got %v", err)
+	}
+	for _, count := range []int{0, MaxDeriveCount + 1} {
+		if _, err := DeriveRange(seed, DefaultPath, 0, count); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("Count %d: expected ErrInvalidRange, got %v", count, err)
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/hashicorp/vault/shamir"
+
+	"key-generator/keystorefmt"
 )
 
 func TestKeystoreRecovery(t *testing.T) {
@@ -120,3 +123,307 @@ func TestKeystoreRecovery(t *testing.T) {
 	fmt.Println("2. Decrypt the keystore to retrieve the share data")
 	fmt.Println("3. Use the original-length shares for Shamir combination")
 }
+
+func TestImportKeystore(t *testing.T) {
+	fmt.Println("=== Import Keystore Test ===")
+
+	app := NewApp()
+
+	// 1. An existing keystore, e.g. of a deployer key
+	fmt.Println("1. Creating an existing keystore...")
+	existing, err := app.GenerateKey("Deployer1!")
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	// 2. Importing shows the address and public key but not the private key
+	fmt.Println("2. Importing the keystore...")
+	imported, err := app.ImportKeystore(existing.Keystore, "Deployer1!")
+	if err != nil {
+		t.Fatal("Failed to import keystore:", err)
+	}
+	if imported.Address != existing.Address || imported.PublicKey != existing.PublicKey || imported.PrivateKey != "" {
+		t.Fatalf("❌ Unexpected import result: %+v", imported)
+	}
+	privateKey, err := app.RevealKeystorePrivateKey(existing.Keystore, "Deployer1!")
+	if err != nil || privateKey != existing.PrivateKey {
+		t.Fatalf("❌ Revealed %q (%v), expected the private key", privateKey, err)
+	}
+	if _, err := app.ImportKeystore(existing.Keystore, "wrong"); err == nil {
+		t.Fatal("❌ Imported a keystore with the wrong password")
+	}
+	fmt.Println("✅ SUCCESS: Imported", imported.Address)
+
+	// 3. The imported key is split into shares that recover it
+	fmt.Println("3. Splitting the imported key...")
+	result, err := app.SplitKeystore(existing.Keystore, "Deployer1!", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split keystore:", err)
+	}
+	if result.PrivateKey != "" {
+		t.Fatal("❌ Split result contains the private key")
+	}
+	recoveredAddress, err := app.VerifyShamirShares(result.Shares[1:], "")
+	if err != nil {
+		t.Fatal("Failed to combine shares:", err)
+	}
+	if recoveredAddress != existing.Address {
+		t.Fatalf("❌ Shares recover %s, expected %s", recoveredAddress, existing.Address)
+	}
+	fmt.Println("✅ SUCCESS: Shares recover the imported key")
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestKDFProfiles(t *testing.T) {
+	fmt.Println("=== KDF Profile Test ===")
+
+	app := NewApp()
+
+	// 1. New keystores use standard scrypt unless a profile is chosen
+	fmt.Println("1. Checking the default profile...")
+	if app.kdf != keystorefmt.KDFStandard {
+		t.Fatalf("❌ Default profile is %s", app.kdf)
+	}
+	if _, err := app.SetKDFProfile("argon2"); err == nil {
+		t.Fatal("❌ Accepted an unknown profile")
+	}
+
+	// 2. Each profile is reported and written into the keystore
+	for _, profile := range []string{"light", "pbkdf2"} {
+		fmt.Printf("2. Generating a key with the %s profile...\n", profile)
+		kdf, err := app.SetKDFProfile(profile)
+		if err != nil {
+			t.Fatal("Failed to set profile:", err)
+		}
+		result, err := app.GenerateKey("Fixture1!")
+		if err != nil {
+			t.Fatal("Failed to generate key:", err)
+		}
+		if result.KDF == nil || *result.KDF != *kdf {
+			t.Fatalf("❌ Result reports %+v, expected %+v", result.KDF, kdf)
+		}
+
+		info, err := keystorefmt.Inspect([]byte(result.Keystore))
+		if err != nil {
+			t.Fatal("Failed to inspect keystore:", err)
+		}
+		if info.KDF != kdf.Function {
+			t.Fatalf("❌ Keystore uses %s, expected %s", info.KDF, kdf.Function)
+		}
+		imported, err := app.ImportKeystore(result.Keystore, "Fixture1!")
+		if err != nil || imported.Address != result.Address {
+			t.Fatalf("❌ Failed to open the %s keystore: %v", profile, err)
+		}
+		fmt.Println("✅ SUCCESS:", kdf.Description)
+	}
+
+	// 3. Share keystores use the profile of the app as well
+	fmt.Println("3. Creating a share keystore with the pbkdf2 profile...")
+	shares, err := app.GenerateShamirShares("", 2, 2)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	shareKeystore, err := app.CreateShareKeystore(shares.Shares[0], "Share1!pass", 1, shares.ShareSet)
+	if err != nil {
+		t.Fatal("Failed to create share keystore:", err)
+	}
+	written, err := keystorefmt.KeystoreKDF([]byte(shareKeystore.Keystore))
+	if err != nil || written != keystorefmt.KDFPBKDF2 || shareKeystore.KDF == nil || shareKeystore.KDF.Profile != "pbkdf2" {
+		t.Fatalf("❌ Share keystore uses %+v, reported %+v: %v", written, shareKeystore.KDF, err)
+	}
+	fmt.Println("✅ SUCCESS:", shareKeystore.KDF.Description)
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestKeystoreErrors(t *testing.T) {
+	fmt.Println("=== Keystore Error Test ===")
+
+	app := NewApp()
+	shares, err := app.GenerateShamirShares("", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+
+	// 1. An scrypt N that is not a power of two cannot encrypt anything
+	fmt.Println("1. Breaking the key derivation...")
+	app.kdf = keystorefmt.KDF{Profile: "broken", Function: "scrypt", N: 3, P: 1}
+
+	// 2. Failures reach the caller instead of an empty keystore
+	fmt.Println("2. Generating and combining with the broken key derivation...")
+	if result, err := app.GenerateKey("Generated1!"); err == nil || result != nil {
+		t.Fatalf("❌ GenerateKey returned %+v, %v", result, err)
+	}
+	if result, err := app.CombineShamirShares(shares.Shares[:2], "Combined1!"); err == nil || result != nil {
+		t.Fatalf("❌ CombineShamirShares returned %+v, %v", result, err)
+	}
+	fmt.Println("✅ SUCCESS: Keystore errors are returned")
+
+	// 3. A working profile produces a keystore that opens with its password
+	fmt.Println("3. Generating with the light profile...")
+	if _, err := app.SetKDFProfile("light"); err != nil {
+		t.Fatal("Failed to set profile:", err)
+	}
+	result, err := app.GenerateKey("Generated1!")
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	if _, err := app.ImportKeystore(result.Keystore, "Generated1!"); err != nil {
+		t.Fatal("❌ Generated keystore does not open:", err)
+	}
+
+	// 4. No keystore is written under a missing or weak password
+	fmt.Println("4. Generating with missing and weak passwords...")
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	for _, password := range []string{"", "generated"} {
+		var policyErr *keystorefmt.PasswordPolicyError
+		if result, err := app.GenerateKey(password); !errors.As(err, &policyErr) || result != nil {
+			t.Fatalf("❌ GenerateKey(%q) returned %+v, %v", password, result, err)
+		}
+		if result, err := app.ImportMnemonic(mnemonic, "", password); !errors.As(err, &policyErr) || result != nil {
+			t.Fatalf("❌ ImportMnemonic with password %q returned %+v, %v", password, result, err)
+		}
+	}
+	fmt.Println("✅ SUCCESS: Weak passwords are refused")
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestChangeKeystorePassword(t *testing.T) {
+	fmt.Println("=== Change Keystore Password Test ===")
+
+	app := NewApp()
+
+	// 1. A keystore whose password a departing operator knew
+	fmt.Println("1. Creating a keystore...")
+	existing, err := app.GenerateKey("Operator1!")
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	// 2. Rotate the password without exporting the key
+	fmt.Println("2. Changing the password...")
+	if _, err := app.ChangeKeystorePassword(existing.Keystore, "Operator1!", "weak", "keep"); err == nil {
+		t.Fatal("❌ Accepted a weak new password")
+	}
+	changed, err := app.ChangeKeystorePassword(existing.Keystore, "Operator1!", "Rotated2@", "keep")
+	if err != nil {
+		t.Fatal("Failed to change password:", err)
+	}
+	if changed.Address != existing.Address || changed.PrivateKey != "" || changed.Keystore == existing.Keystore {
+		t.Fatalf("❌ Unexpected result: %+v", changed)
+	}
+	if changed.KDF == nil || changed.KDF.Profile != "standard" {
+		t.Fatalf("❌ Key derivation changed to %+v", changed.KDF)
+	}
+
+	// 3. Only the new password opens the new keystore
+	fmt.Println("3. Opening the new keystore...")
+	privateKey, err := app.RevealKeystorePrivateKey(changed.Keystore, "Rotated2@")
+	if err != nil || privateKey != existing.PrivateKey {
+		t.Fatalf("❌ Revealed %q (%v), expected the original private key", privateKey, err)
+	}
+	if _, err := app.ImportKeystore(changed.Keystore, "Operator1!"); err == nil {
+		t.Fatal("❌ Old password still opens the new keystore")
+	}
+	fmt.Println("✅ SUCCESS: Password changed for", changed.Address)
+
+	// 4. A profile is only applied when the caller picks one
+	fmt.Println("4. Changing the password and the key derivation...")
+	if _, err := app.SetKDFProfile("light"); err != nil {
+		t.Fatal("Failed to set profile:", err)
+	}
+	kept, err := app.ChangeKeystorePassword(changed.Keystore, "Rotated2@", "Rotated3#", "")
+	if err != nil || kept.KDF.Profile != "standard" {
+		t.Fatalf("❌ Expected the standard key derivation to be kept, got %+v (%v)", kept, err)
+	}
+	upgraded, err := app.ChangeKeystorePassword(changed.Keystore, "Rotated2@", "Rotated3#", "pbkdf2")
+	if err != nil || upgraded.KDF.Profile != "pbkdf2" {
+		t.Fatalf("❌ Expected pbkdf2, got %+v (%v)", upgraded, err)
+	}
+	if _, err := app.ChangeKeystorePassword(changed.Keystore, "Rotated2@", "Rotated3#", "argon2"); err == nil {
+		t.Fatal("❌ Accepted an unknown profile")
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestSplitPrivateKey(t *testing.T) {
+	fmt.Println("=== Split Existing Private Key Test ===")
+
+	app := NewApp()
+
+	// 1. An existing treasury key
+	fmt.Println("1. Creating an existing key...")
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	// 2. Split it 3 of 5 without handing the private key back
+	fmt.Println("2. Splitting the existing key...")
+	result, err := app.SplitPrivateKey(fmt.Sprintf("0x%x", crypto.FromECDSA(privateKey)), 5, 3)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	if result.Address != address.Hex() || result.PrivateKey != "" || result.ShareSet.Threshold != 3 || result.ShareSet.Total != 5 {
+		t.Fatalf("❌ Unexpected split result: address %s, set %+v", result.Address, result.ShareSet)
+	}
+
+	// 3. Any three shares recover the existing address
+	fmt.Println("3. Recovering from shares 1, 3 and 5...")
+	recoveredAddress, err := app.VerifyShamirShares([]string{result.Shares[0], result.Shares[2], result.Shares[4]}, "")
+	if err != nil {
+		t.Fatal("Failed to combine shares:", err)
+	}
+	if recoveredAddress != address.Hex() {
+		t.Fatalf("❌ Shares recover %s, expected %s", recoveredAddress, address.Hex())
+	}
+	fmt.Println("✅ SUCCESS: Shares recover", recoveredAddress)
+
+	if _, err := app.SplitPrivateKey("not a key", 5, 3); err == nil {
+		t.Fatal("❌ Split an invalid private key")
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestDeriveMnemonicKeys(t *testing.T) {
+	fmt.Println("=== Mnemonic Derivation Test ===")
+
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	app := NewApp()
+	if _, err := app.SetKDFProfile("light"); err != nil {
+		t.Fatal("Failed to set profile:", err)
+	}
+
+	// 1. Listing accounts returns addresses only
+	fmt.Println("1. Listing accounts...")
+	listed, err := app.DeriveMnemonicKeys(mnemonic, "", "", 0, 2, "")
+	if err != nil {
+		t.Fatal("Failed to list accounts:", err)
+	}
+	for _, account := range listed {
+		if account.Address == "" || account.PrivateKey != "" || account.Keystore != "" {
+			t.Fatalf("❌ Listed account %s carries key material", account.Path)
+		}
+	}
+	fmt.Println("✅ SUCCESS: No private keys in the listing")
+
+	// 2. Exporting keystores returns the keys of the same accounts
+	fmt.Println("2. Exporting keystores...")
+	exported, err := app.DeriveMnemonicKeys(mnemonic, "", "", 0, 2, "Fixture1!")
+	if err != nil {
+		t.Fatal("Failed to export accounts:", err)
+	}
+	for i, account := range exported {
+		if account.Address != listed[i].Address || account.PrivateKey == "" || account.Keystore == "" {
+			t.Fatalf("❌ Unexpected export of %s", account.Path)
+		}
+	}
+	fmt.Println("✅ SUCCESS: Keystores exported")
+
+	fmt.Println("\n=== Test Complete ===")
+}
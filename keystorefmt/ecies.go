@@ -0,0 +1,128 @@
+package keystorefmt
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+
+	"key-generator/sharing"
+)
+
+// CipherECIES is the cipher of share keystores encrypted to a custodian's
+// secp256k1 public key with go-ethereum's ECIES (AES-128-CTR, HMAC-SHA-256)
+const CipherECIES = "ecies-secp256k1"
+
+var (
+	// ErrCustodianShare is returned when a share encrypted to a custodian's
+	// public key is opened with a password
+	ErrCustodianShare = errors.New("share is encrypted to a custodian public key")
+
+	// ErrInvalidRecipient is returned for custodian public keys that are
+	// neither secp256k1 nor X25519 keys
+	ErrInvalidRecipient = errors.New("invalid custodian public key")
+
+	// ErrWrongRecipient is returned when a custodian share is opened with a
+	// key other than the one it was encrypted to
+	ErrWrongRecipient = errors.New("share is encrypted to a different custodian")
+)
+
+// Recipient is the public key of a custodian; exactly one of Secp256k1 and
+// X25519 is set
+type Recipient struct {
+	Secp256k1 *ecdsa.PublicKey
+	X25519    *ecdh.PublicKey
+}
+
+// ParseRecipient parses a hex custodian public key, with or without 0x
+// prefix: a compressed (33 bytes) or uncompressed (65 bytes) secp256k1 key, or
+// a 32-byte X25519 key
+func ParseRecipient(publicKeyHex string) (*Recipient, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(publicKeyHex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecipient, err)
+	}
+
+	var recipient Recipient
+	switch len(raw) {
+	case 33:
+		recipient.Secp256k1, err = crypto.DecompressPubkey(raw)
+	case 65:
+		recipient.Secp256k1, err = crypto.UnmarshalPubkey(raw)
+	case 32:
+		recipient.X25519, err = ecdh.X25519().NewPublicKey(raw)
+	default:
+		return nil, fmt.Errorf("%w: %d bytes, expected a 33 or 65-byte secp256k1 key or a 32-byte X25519 key", ErrInvalidRecipient, len(raw))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecipient, err)
+	}
+	return &recipient, nil
+}
+
+// EncryptShareTo encrypts a share to a custodian's public key, so the share
+// keystore can be sent to its holder without a password. secp256k1 keys use
+// ECIES, X25519 keys CipherX25519. The recipient's public key, compressed for
+// secp256k1, is recorded in the keystore.
+func EncryptShareTo(share sharing.Share, recipient *Recipient) ([]byte, error) {
+	if recipient.X25519 != nil {
+		return encryptShareToX25519(share, recipient.X25519)
+	}
+
+	ciphertext, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(recipient.Secp256k1), share.Value, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt share: %w", err)
+	}
+
+	shareKeystore := newShareKeystore(share, keystore.CryptoJSON{
+		Cipher:     CipherECIES,
+		CipherText: hex.EncodeToString(ciphertext),
+	})
+	shareKeystore.Recipient = hex.EncodeToString(crypto.CompressPubkey(recipient.Secp256k1))
+
+	return json.MarshalIndent(shareKeystore, "", "  ")
+}
+
+// DecryptShareWith decrypts a share keystore encrypted to a custodian's
+// secp256k1 public key with the custodian's private key. The ECIES MAC is
+// verified before decrypting.
+func DecryptShareWith(keystoreJSON []byte, privateKey *ecdsa.PrivateKey) (*sharing.Share, error) {
+	shareKeystore, err := parseShareKeystore(keystoreJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRecipient(shareKeystore, CipherECIES, hex.EncodeToString(crypto.CompressPubkey(&privateKey.PublicKey))); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := hex.DecodeString(shareKeystore.Crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	value, err := ecies.ImportECDSA(privateKey).Decrypt(ciphertext, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt share %d: %w", shareKeystore.ShareIndex, err)
+	}
+
+	return shareFromKeystore(shareKeystore, value)
+}
+
+// checkRecipient checks that a share keystore is encrypted with cipherName to
+// the custodian public key recipient
+func checkRecipient(shareKeystore *ShareKeystore, cipherName string, recipient string) error {
+	if shareKeystore.Recipient == "" {
+		return fmt.Errorf("%w: share %d is not encrypted to a custodian, open it with its password", ErrInvalidKeystore, shareKeystore.ShareIndex)
+	}
+	if shareKeystore.Crypto.Cipher != cipherName || shareKeystore.Recipient != recipient {
+		return fmt.Errorf("%w: share %d is for %s, not %s", ErrWrongRecipient, shareKeystore.ShareIndex, shareKeystore.Recipient, recipient)
+	}
+	return nil
+}
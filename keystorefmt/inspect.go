@@ -0,0 +1,66 @@
+package keystorefmt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Info describes a keystore or share keystore without decrypting it
+type Info struct {
+	Type       string                 `json:"type"`
+	Address    string                 `json:"address"`
+	ID         string                 `json:"id"`
+	Version    int                    `json:"version"`
+	ShareIndex int                    `json:"shareIndex,omitempty"`
+	ShareGroup int                    `json:"shareGroup,omitempty"`
+	ShareSet   *ShareSetJSON          `json:"shareSet,omitempty"`
+	Recipient  string                 `json:"recipient,omitempty"`
+	Cipher     string                 `json:"cipher"`
+	KDF        string                 `json:"kdf"`
+	KDFParams  map[string]interface{} `json:"kdfparams"`
+}
+
+// Inspect reads the public metadata of a keystore or share keystore
+func Inspect(keystoreJSON []byte) (*Info, error) {
+	var parsed struct {
+		Address    string              `json:"address"`
+		ID         string              `json:"id"`
+		Version    int                 `json:"version"`
+		ShareIndex *int                `json:"shareIndex"`
+		ShareGroup int                 `json:"shareGroup"`
+		ShareSet   *ShareSetJSON       `json:"shareSet"`
+		Recipient  string              `json:"recipient"`
+		Crypto     keystore.CryptoJSON `json:"crypto"`
+	}
+	if err := json.Unmarshal(keystoreJSON, &parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	if parsed.Crypto.CipherText == "" {
+		return nil, fmt.Errorf("%w: no encrypted data", ErrInvalidKeystore)
+	}
+
+	info := &Info{
+		Type:      "keystore",
+		Address:   parsed.Address,
+		ID:        parsed.ID,
+		Version:   parsed.Version,
+		ShareSet:  parsed.ShareSet,
+		Recipient: parsed.Recipient,
+		Cipher:    parsed.Crypto.Cipher,
+		KDF:       parsed.Crypto.KDF,
+		KDFParams: parsed.Crypto.KDFParams,
+	}
+	if parsed.ShareIndex != nil {
+		info.Type = "share"
+		info.ShareIndex = *parsed.ShareIndex
+		info.ShareGroup = parsed.ShareGroup
+	}
+	if common.IsHexAddress(parsed.Address) {
+		info.Address = common.HexToAddress(parsed.Address).Hex()
+	}
+
+	return info, nil
+}
@@ -0,0 +1,174 @@
+package keystorefmt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrInvalidKDF is returned for an unknown key derivation profile
+var ErrInvalidKDF = errors.New("invalid key derivation profile")
+
+// KDF selects the key derivation of new keystores. Scrypt profiles set N, R
+// and P, the pbkdf2 profile sets Iterations.
+type KDF struct {
+	Profile    string
+	Function   string
+	N          int
+	R          int
+	P          int
+	Iterations int
+	SaltLength int
+}
+
+// ScryptR is the scrypt block size of all scrypt profiles
+const ScryptR = 8
+
+// dkLen is the length of derived keys, split into an AES key and a MAC key
+const dkLen = 32
+
+var (
+	// KDFLight is fast enough for test keys and CI fixtures, not for real funds
+	KDFLight = KDF{Profile: "light", Function: "scrypt", N: keystore.LightScryptN, R: ScryptR, P: keystore.LightScryptP, SaltLength: 32}
+
+	// KDFStandard uses the go-ethereum standard scrypt parameters
+	KDFStandard = KDF{Profile: "standard", Function: "scrypt", N: keystore.StandardScryptN, R: ScryptR, P: keystore.StandardScryptP, SaltLength: 32}
+
+	// KDFParanoid uses four times the standard work factor, needing 1 GiB of
+	// memory to decrypt, for cold storage keys
+	KDFParanoid = KDF{Profile: "paranoid", Function: "scrypt", N: 1 << 20, R: ScryptR, P: 1, SaltLength: 32}
+
+	// KDFPBKDF2 uses PBKDF2-HMAC-SHA256 for consumers that cannot do scrypt
+	KDFPBKDF2 = KDF{Profile: "pbkdf2", Function: "pbkdf2", Iterations: 262144, SaltLength: 32}
+)
+
+// KDFProfiles lists the key derivation profiles by name
+var KDFProfiles = []KDF{KDFLight, KDFStandard, KDFParanoid, KDFPBKDF2}
+
+// ParseKDF returns the key derivation profile of the given name; an empty
+// name selects the standard profile
+func ParseKDF(profile string) (KDF, error) {
+	profile = strings.ToLower(strings.TrimSpace(profile))
+	if profile == "" {
+		return KDFStandard, nil
+	}
+	for _, kdf := range KDFProfiles {
+		if kdf.Profile == profile {
+			return kdf, nil
+		}
+	}
+	return KDF{}, fmt.Errorf("%w: %q, expected light, standard, paranoid or pbkdf2", ErrInvalidKDF, profile)
+}
+
+// KeystoreKDF reads the key derivation parameters of a V3 keystore. Profile
+// names the matching profile, or is "custom" for parameters of other tools.
+func KeystoreKDF(keystoreJSON []byte) (KDF, error) {
+	info, err := Inspect(keystoreJSON)
+	if err != nil {
+		return KDF{}, err
+	}
+
+	param := func(name string) int {
+		value, _ := info.KDFParams[name].(float64)
+		return int(value)
+	}
+	salt, _ := info.KDFParams["salt"].(string)
+	kdf := KDF{Profile: "custom", Function: info.KDF, SaltLength: len(salt) / 2}
+	switch info.KDF {
+	case "scrypt":
+		kdf.N, kdf.R, kdf.P = param("n"), param("r"), param("p")
+		if kdf.N <= 1 || kdf.R < 1 || kdf.P < 1 {
+			return KDF{}, fmt.Errorf("%w: invalid scrypt parameters", ErrInvalidKeystore)
+		}
+	case "pbkdf2":
+		kdf.Iterations = param("c")
+		if prf, _ := info.KDFParams["prf"].(string); prf != "hmac-sha256" || kdf.Iterations < 1 {
+			return KDF{}, fmt.Errorf("%w: invalid pbkdf2 parameters", ErrInvalidKeystore)
+		}
+	default:
+		return KDF{}, fmt.Errorf("%w: unsupported key derivation %q", ErrInvalidKeystore, info.KDF)
+	}
+	if kdf.SaltLength == 0 {
+		return KDF{}, fmt.Errorf("%w: no salt", ErrInvalidKeystore)
+	}
+
+	for _, profile := range KDFProfiles {
+		match := kdf
+		match.Profile = profile.Profile
+		if match == profile {
+			kdf.Profile = profile.Profile
+			break
+		}
+	}
+	return kdf, nil
+}
+
+// String describes the profile and its parameters, for example
+// "standard (scrypt N=262144 r=8 p=1)"
+func (k KDF) String() string {
+	if k.Function == "pbkdf2" {
+		return fmt.Sprintf("%s (pbkdf2 hmac-sha256 c=%d)", k.Profile, k.Iterations)
+	}
+	return fmt.Sprintf("%s (scrypt N=%d r=%d p=%d)", k.Profile, k.N, k.R, k.P)
+}
+
+// encryptData encrypts data with AES-128-CTR under a key derived from the
+// password, with a fresh salt and IV and the Keccak MAC of V3 keystores.
+// go-ethereum only writes its fixed scrypt block size and salt length and no
+// pbkdf2 at all, so the key derivation is done here.
+func (k KDF) encryptData(data []byte, password string) (keystore.CryptoJSON, error) {
+	salt := make([]byte, k.SaltLength)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+
+	var derivedKey []byte
+	var err error
+	var params map[string]interface{}
+	switch k.Function {
+	case "scrypt":
+		derivedKey, err = scrypt.Key([]byte(password), salt, k.N, k.R, k.P, dkLen)
+		params = map[string]interface{}{"n": k.N, "r": k.R, "p": k.P}
+	case "pbkdf2":
+		derivedKey, err = pbkdf2.Key(sha256.New, password, salt, k.Iterations, dkLen)
+		params = map[string]interface{}{"c": k.Iterations, "prf": "hmac-sha256"}
+	default:
+		err = fmt.Errorf("%w: key derivation %q", ErrInvalidKDF, k.Function)
+	}
+	if err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	params["dklen"] = dkLen
+	params["salt"] = hex.EncodeToString(salt)
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	cipherText := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, data)
+
+	cryptoStruct := keystore.CryptoJSON{
+		Cipher:     "aes-128-ctr",
+		CipherText: hex.EncodeToString(cipherText),
+		KDF:        k.Function,
+		KDFParams:  params,
+		MAC:        hex.EncodeToString(crypto.Keccak256(derivedKey[16:32], cipherText)),
+	}
+	cryptoStruct.CipherParams.IV = hex.EncodeToString(iv)
+	return cryptoStruct, nil
+}
@@ -0,0 +1,125 @@
+// Package keystorefmt reads and writes Web3 Secret Storage keystores and the
+// share keystores used for Shamir shares.
+package keystorefmt
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+
+	"key-generator/keygen"
+)
+
+var (
+	// ErrIncorrectPassword is returned when a keystore MAC does not match the password
+	ErrIncorrectPassword = errors.New("incorrect password")
+
+	// ErrInvalidKeystore is returned when JSON is not a keystore this package understands
+	ErrInvalidKeystore = errors.New("invalid keystore")
+)
+
+// keyJSON is the V3 keystore layout written by go-ethereum
+type keyJSON struct {
+	Address string              `json:"address"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+	ID      string              `json:"id"`
+	Version int                 `json:"version"`
+}
+
+// EncryptKey encrypts a private key into a V3 keystore with standard scrypt parameters
+func EncryptKey(privateKey *ecdsa.PrivateKey, password string) ([]byte, error) {
+	return EncryptKeyWith(privateKey, password, KDFStandard)
+}
+
+// EncryptKeyWith encrypts a private key into a V3 keystore with the key
+// derivation of the given profile
+func EncryptKeyWith(privateKey *ecdsa.PrivateKey, password string, kdf KDF) ([]byte, error) {
+	// Encrypt in memory; a directory-backed KeyStore would write key files to
+	// the working directory and refuse addresses already present there
+	return encryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}, password, kdf)
+}
+
+// encryptKey encrypts a key with a fresh salt and IV
+func encryptKey(key *keystore.Key, password string, kdf KDF) ([]byte, error) {
+	cryptoStruct, err := kdf.encryptData(crypto.FromECDSA(key.PrivateKey), password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt key: %w", err)
+	}
+
+	return json.Marshal(keyJSON{
+		Address: hex.EncodeToString(key.Address[:]),
+		Crypto:  cryptoStruct,
+		ID:      key.Id.String(),
+		Version: 3,
+	})
+}
+
+// DecryptKey verifies the MAC of a V3 keystore with scrypt or pbkdf2 key
+// derivation and decrypts its private key
+func DecryptKey(keystoreJSON []byte, password string) (*keygen.Key, error) {
+	key, err := decryptKey(keystoreJSON, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return keygen.FromECDSA(key.PrivateKey), nil
+}
+
+// ChangePassword re-encrypts a V3 keystore under a new password with a fresh
+// salt and IV. A nil kdf keeps the key derivation parameters of the keystore.
+// The address and keystore UUID stay the same.
+func ChangePassword(keystoreJSON []byte, oldPassword string, newPassword string, kdf *KDF) ([]byte, error) {
+	key, err := decryptKey(keystoreJSON, oldPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if kdf == nil {
+		source, err := KeystoreKDF(keystoreJSON)
+		if err != nil {
+			return nil, err
+		}
+		kdf = &source
+	}
+	return encryptKey(key, newPassword, *kdf)
+}
+
+// decryptKey decrypts a V3 keystore after checking its key derivation and
+// that its address matches the key
+func decryptKey(keystoreJSON []byte, password string) (*keystore.Key, error) {
+	info, err := Inspect(keystoreJSON)
+	if err != nil {
+		return nil, err
+	}
+	if info.Type != "keystore" {
+		return nil, fmt.Errorf("%w: a share keystore holds a share, not a key", ErrInvalidKeystore)
+	}
+	if info.KDF != "scrypt" && info.KDF != "pbkdf2" {
+		return nil, fmt.Errorf("%w: unsupported key derivation %q", ErrInvalidKeystore, info.KDF)
+	}
+
+	key, err := keystore.DecryptKey(keystoreJSON, password)
+	if err != nil {
+		if errors.Is(err, keystore.ErrDecrypt) {
+			return nil, ErrIncorrectPassword
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+
+	// The address field is not covered by the MAC, so it must match the key
+	if info.Address != "" && info.Address != key.Address.Hex() {
+		return nil, fmt.Errorf("%w: keystore address %s does not match its key %s", ErrInvalidKeystore, info.Address, key.Address.Hex())
+	}
+
+	return key, nil
+}
@@ -0,0 +1,463 @@
+package keystorefmt
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+
+	"key-generator/keygen"
+	"key-generator/sharing"
+)
+
+func TestEncryptKey(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	keystoreJSON, err := EncryptKey(key.PrivateKey, "Passw0rd!")
+	if err != nil {
+		t.Fatal("Failed to encrypt key:", err)
+	}
+
+	decrypted, err := keystore.DecryptKey(keystoreJSON, "Passw0rd!")
+	if err != nil {
+		t.Fatal("Failed to decrypt keystore:", err)
+	}
+	if decrypted.Address != key.Address {
+		t.Errorf("Decrypted address %s, expected %s", decrypted.Address.Hex(), key.Address.Hex())
+	}
+}
+
+// pbkdf2Keystore is the pbkdf2 test vector of the Web3 Secret Storage definition
+const pbkdf2Keystore = `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2","kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`
+
+func TestDecryptKey(t *testing.T) {
+	key, err := DecryptKey([]byte(pbkdf2Keystore), "testpassword")
+	if err != nil {
+		t.Fatal("Failed to decrypt pbkdf2 keystore:", err)
+	}
+	if key.PrivateKeyHex() != "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d" {
+		t.Errorf("Unexpected private key %s", key.PrivateKeyHex())
+	}
+
+	generated, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	keystoreJSON, err := EncryptKey(generated.PrivateKey, "Passw0rd!")
+	if err != nil {
+		t.Fatal("Failed to encrypt key:", err)
+	}
+	key, err = DecryptKey(keystoreJSON, "Passw0rd!")
+	if err != nil {
+		t.Fatal("Failed to decrypt scrypt keystore:", err)
+	}
+	if key.Address != generated.Address {
+		t.Errorf("Decrypted address %s, expected %s", key.Address.Hex(), generated.Address.Hex())
+	}
+
+	if _, err := DecryptKey(keystoreJSON, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+	tampered := bytes.Replace(keystoreJSON, []byte(strings.ToLower(generated.Address.Hex()[2:])), []byte(strings.Repeat("0", 40)), 1)
+	if _, err := DecryptKey(tampered, "Passw0rd!"); !errors.Is(err, ErrInvalidKeystore) {
+		t.Errorf("Expected ErrInvalidKeystore for a changed address, got %v", err)
+	}
+}
+
+func TestEncryptKeyWith(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	for _, kdf := range []KDF{KDFLight, KDFPBKDF2} {
+		keystoreJSON, err := EncryptKeyWith(key.PrivateKey, "Passw0rd!", kdf)
+		if err != nil {
+			t.Fatalf("%s: failed to encrypt key: %v", kdf.Profile, err)
+		}
+		info, err := Inspect(keystoreJSON)
+		if err != nil {
+			t.Fatalf("%s: failed to inspect keystore: %v", kdf.Profile, err)
+		}
+		if info.KDF != kdf.Function || info.Address != key.Address.Hex() {
+			t.Errorf("%s: keystore has kdf %s and address %s", kdf.Profile, info.KDF, info.Address)
+		}
+
+		// go-ethereum must read what we write, pbkdf2 included
+		decrypted, err := keystore.DecryptKey(keystoreJSON, "Passw0rd!")
+		if err != nil {
+			t.Fatalf("%s: failed to decrypt keystore: %v", kdf.Profile, err)
+		}
+		if decrypted.Address != key.Address {
+			t.Errorf("%s: decrypted address %s, expected %s", kdf.Profile, decrypted.Address.Hex(), key.Address.Hex())
+		}
+		if _, err := DecryptKey(keystoreJSON, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+			t.Errorf("%s: expected ErrIncorrectPassword, got %v", kdf.Profile, err)
+		}
+	}
+}
+
+func TestEncryptShareKDF(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	shares, err := sharing.SplitKey(key, 2, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+
+	for _, kdf := range []KDF{KDFLight, KDFPBKDF2} {
+		keystoreJSON, err := EncryptShare(shares[0], "Share1!pass", kdf)
+		if err != nil {
+			t.Fatalf("%s: failed to encrypt share: %v", kdf.Profile, err)
+		}
+		written, err := KeystoreKDF(keystoreJSON)
+		if err != nil || written != kdf {
+			t.Errorf("%s: share keystore has kdf %+v: %v", kdf.Profile, written, err)
+		}
+
+		share, err := DecryptShare(keystoreJSON, "Share1!pass")
+		if err != nil {
+			t.Fatalf("%s: failed to decrypt share: %v", kdf.Profile, err)
+		}
+		if !bytes.Equal(share.Value, shares[0].Value) {
+			t.Errorf("%s: decrypted share does not match", kdf.Profile)
+		}
+		if _, err := DecryptShare(keystoreJSON, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+			t.Errorf("%s: expected ErrIncorrectPassword, got %v", kdf.Profile, err)
+		}
+	}
+}
+
+func TestParseKDF(t *testing.T) {
+	for profile, expected := range map[string]KDF{
+		"":         KDFStandard,
+		"light":    KDFLight,
+		"Standard": KDFStandard,
+		"paranoid": KDFParanoid,
+		"pbkdf2":   KDFPBKDF2,
+	} {
+		kdf, err := ParseKDF(profile)
+		if err != nil || kdf != expected {
+			t.Errorf("ParseKDF(%q) = %v, %v", profile, kdf, err)
+		}
+	}
+	if KDFParanoid.N <= KDFStandard.N {
+		t.Errorf("Paranoid N %d is not above standard N %d", KDFParanoid.N, KDFStandard.N)
+	}
+	if _, err := ParseKDF("argon2"); !errors.Is(err, ErrInvalidKDF) {
+		t.Errorf("Expected ErrInvalidKDF, got %v", err)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	keystoreJSON, err := EncryptKey(key.PrivateKey, "Passw0rd!")
+	if err != nil {
+		t.Fatal("Failed to encrypt key:", err)
+	}
+
+	changed, err := ChangePassword(keystoreJSON, "Passw0rd!", "N3wPassw0rd!", nil)
+	if err != nil {
+		t.Fatal("Failed to change password:", err)
+	}
+	before, err := Inspect(keystoreJSON)
+	if err != nil {
+		t.Fatal("Failed to inspect keystore:", err)
+	}
+	after, err := Inspect(changed)
+	if err != nil {
+		t.Fatal("Failed to inspect changed keystore:", err)
+	}
+	if after.ID != before.ID || after.Address != before.Address {
+		t.Errorf("Changed keystore is %s %s, expected %s %s", after.ID, after.Address, before.ID, before.Address)
+	}
+	if bytes.Equal(changed, keystoreJSON) {
+		t.Error("Changed keystore equals the original")
+	}
+
+	decrypted, err := DecryptKey(changed, "N3wPassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to decrypt with the new password:", err)
+	}
+	if decrypted.Address != key.Address {
+		t.Errorf("Decrypted address %s, expected %s", decrypted.Address.Hex(), key.Address.Hex())
+	}
+	if _, err := DecryptKey(changed, "Passw0rd!"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword for the old password, got %v", err)
+	}
+	if _, err := ChangePassword(keystoreJSON, "wrong", "N3wPassw0rd!", nil); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+}
+
+func TestChangePasswordKeepsKDF(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	for _, kdf := range []KDF{KDFParanoid, KDFPBKDF2} {
+		keystoreJSON, err := EncryptKeyWith(key.PrivateKey, "Passw0rd!", kdf)
+		if err != nil {
+			t.Fatalf("%s: failed to encrypt key: %v", kdf.Profile, err)
+		}
+		changed, err := ChangePassword(keystoreJSON, "Passw0rd!", "N3wPassw0rd!", nil)
+		if err != nil {
+			t.Fatalf("%s: failed to change password: %v", kdf.Profile, err)
+		}
+		kept, err := KeystoreKDF(changed)
+		if err != nil {
+			t.Fatalf("%s: failed to read key derivation: %v", kdf.Profile, err)
+		}
+		if kept != kdf {
+			t.Errorf("Changing the password turned %v into %v", kdf, kept)
+		}
+	}
+	if KDFParanoid.N != 1<<20 {
+		t.Errorf("Paranoid N is %d, expected 2^20", KDFParanoid.N)
+	}
+
+	// Parameters of other tools, such as a 16 byte salt, are kept as well
+	custom := KDF{Profile: "custom", Function: "scrypt", N: 1 << 12, R: 4, P: 2, SaltLength: 16}
+	keystoreJSON, err := EncryptKeyWith(key.PrivateKey, "Passw0rd!", custom)
+	if err != nil {
+		t.Fatal("Failed to encrypt key:", err)
+	}
+	changed, err := ChangePassword(keystoreJSON, "Passw0rd!", "N3wPassw0rd!", nil)
+	if err != nil {
+		t.Fatal("Failed to change password:", err)
+	}
+	if kept, err := KeystoreKDF(changed); err != nil || kept != custom {
+		t.Errorf("Kept %v (%v), expected %v", kept, err, custom)
+	}
+	light := KDFLight
+	changed, err = ChangePassword(keystoreJSON, "Passw0rd!", "N3wPassw0rd!", &light)
+	if err != nil {
+		t.Fatal("Failed to change password:", err)
+	}
+	if chosen, err := KeystoreKDF(changed); err != nil || chosen != KDFLight {
+		t.Errorf("Wrote %v (%v), expected %v", chosen, err, KDFLight)
+	}
+}
+
+func TestShareKeystoreRoundTrip(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	shares, err := sharing.SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+
+	keystoreJSON, err := EncryptShare(shares[1], "Share2!pass", KDFStandard)
+	if err != nil {
+		t.Fatal("Failed to encrypt share:", err)
+	}
+	if bytes.Contains(keystoreJSON, []byte(key.PrivateKeyHex())) {
+		t.Fatal("Share keystore contains the private key")
+	}
+
+	share, err := DecryptShare(keystoreJSON, "Share2!pass")
+	if err != nil {
+		t.Fatal("Failed to decrypt share:", err)
+	}
+	if share.Index != 2 || !bytes.Equal(share.Value, shares[1].Value) {
+		t.Errorf("Decrypted share does not match: index %d", share.Index)
+	}
+	if !share.Set.CreatedAt.Equal(shares[1].Set.CreatedAt) {
+		t.Errorf("Decrypted creation time %v, expected %v", share.Set.CreatedAt, shares[1].Set.CreatedAt)
+	}
+	share.Set.CreatedAt = shares[1].Set.CreatedAt
+	if !reflect.DeepEqual(share.Set, shares[1].Set) {
+		t.Errorf("Decrypted share set %+v, expected %+v", share.Set, shares[1].Set)
+	}
+
+	if _, err := DecryptShare(keystoreJSON, "wrong"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+	if _, err := DecryptShare([]byte(`{"version":3}`), "Share2!pass"); !errors.Is(err, ErrInvalidKeystore) {
+		t.Errorf("Expected ErrInvalidKeystore, got %v", err)
+	}
+
+	info, err := Inspect(keystoreJSON)
+	if err != nil {
+		t.Fatal("Failed to inspect share keystore:", err)
+	}
+	if info.Type != "share" || info.ShareIndex != 2 || info.Address != key.Address.Hex() || info.ShareSet == nil || info.ShareSet.ID != shares[1].Set.ID || info.ShareSet.Kind != "private-key" {
+		t.Errorf("Unexpected share keystore info: %+v", info)
+	}
+}
+
+func TestVerifiableShareKeystore(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	shares, err := sharing.SplitKeyVerifiable(key, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+
+	keystoreJSON, err := EncryptShare(shares[0], "Share1!pass", KDFLight)
+	if err != nil {
+		t.Fatal("Failed to encrypt share:", err)
+	}
+	share, err := DecryptShare(keystoreJSON, "Share1!pass")
+	if err != nil {
+		t.Fatal("Failed to decrypt share:", err)
+	}
+	if !reflect.DeepEqual(share.Set.Commitments, shares[0].Set.Commitments) {
+		t.Errorf("Decrypted commitments %x, expected %x", share.Set.Commitments, shares[0].Set.Commitments)
+	}
+	if err := sharing.VerifyShare(*share); err != nil {
+		t.Errorf("Decrypted share failed verification: %v", err)
+	}
+
+	info, err := Inspect(keystoreJSON)
+	if err != nil {
+		t.Fatal("Failed to inspect share keystore:", err)
+	}
+	if len(info.ShareSet.Commitments) != 2 {
+		t.Errorf("Expected 2 commitments in share keystore info, got %d", len(info.ShareSet.Commitments))
+	}
+}
+
+func TestCustodianShare(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	custodian, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate custodian key:", err)
+	}
+	shares, err := sharing.SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+
+	recipient, err := ParseRecipient(custodian.PublicKeyHex())
+	if err != nil {
+		t.Fatal("Failed to parse recipient:", err)
+	}
+	keystoreJSON, err := EncryptShareTo(shares[1], recipient)
+	if err != nil {
+		t.Fatal("Failed to encrypt share:", err)
+	}
+
+	share, err := DecryptShareWith(keystoreJSON, custodian.PrivateKey)
+	if err != nil {
+		t.Fatal("Failed to decrypt share:", err)
+	}
+	if share.Index != 2 || !bytes.Equal(share.Value, shares[1].Value) || share.Set.ID != shares[1].Set.ID {
+		t.Errorf("Decrypted share does not match: index %d", share.Index)
+	}
+
+	if _, err := DecryptShareWith(keystoreJSON, key.PrivateKey); !errors.Is(err, ErrWrongRecipient) {
+		t.Errorf("Expected ErrWrongRecipient, got %v", err)
+	}
+	if _, err := DecryptShare(keystoreJSON, "Share2!pass"); !errors.Is(err, ErrCustodianShare) {
+		t.Errorf("Expected ErrCustodianShare, got %v", err)
+	}
+	if _, err := DecryptShareWithX25519(keystoreJSON, newX25519Key(t)); !errors.Is(err, ErrWrongRecipient) {
+		t.Errorf("Expected ErrWrongRecipient for an X25519 key, got %v", err)
+	}
+	if _, err := ParseRecipient(strings.Repeat("ab", 20)); !errors.Is(err, ErrInvalidRecipient) {
+		t.Errorf("Expected ErrInvalidRecipient for a 20-byte key, got %v", err)
+	}
+}
+
+func TestX25519CustodianShare(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	shares, err := sharing.SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	custodian := newX25519Key(t)
+
+	recipient, err := ParseRecipient(hex.EncodeToString(custodian.PublicKey().Bytes()))
+	if err != nil || recipient.X25519 == nil {
+		t.Fatalf("Failed to parse X25519 recipient: %v", err)
+	}
+	keystoreJSON, err := EncryptShareTo(shares[0], recipient)
+	if err != nil {
+		t.Fatal("Failed to encrypt share:", err)
+	}
+	info, err := Inspect(keystoreJSON)
+	if err != nil || info.Cipher != CipherX25519 || info.Recipient != hex.EncodeToString(custodian.PublicKey().Bytes()) {
+		t.Fatalf("Unexpected share keystore info %+v: %v", info, err)
+	}
+
+	share, err := DecryptShareWithX25519(keystoreJSON, custodian)
+	if err != nil {
+		t.Fatal("Failed to decrypt share:", err)
+	}
+	if share.Index != 1 || !bytes.Equal(share.Value, shares[0].Value) || share.Set.ID != shares[0].Set.ID {
+		t.Errorf("Decrypted share does not match: index %d", share.Index)
+	}
+
+	if _, err := DecryptShareWithX25519(keystoreJSON, newX25519Key(t)); !errors.Is(err, ErrWrongRecipient) {
+		t.Errorf("Expected ErrWrongRecipient, got %v", err)
+	}
+	if _, err := DecryptShareWith(keystoreJSON, key.PrivateKey); !errors.Is(err, ErrWrongRecipient) {
+		t.Errorf("Expected ErrWrongRecipient for a secp256k1 key, got %v", err)
+	}
+	if _, err := DecryptShare(keystoreJSON, "Share1!pass"); !errors.Is(err, ErrCustodianShare) {
+		t.Errorf("Expected ErrCustodianShare, got %v", err)
+	}
+
+	// A modified ciphertext fails the GCM tag
+	tampered := bytes.Replace(keystoreJSON, []byte(`"ciphertext": "`), []byte(`"ciphertext": "00`), 1)
+	if _, err := DecryptShareWithX25519(tampered, custodian); err == nil {
+		t.Error("Tampered share decrypted")
+	}
+
+	parsed, err := ParseX25519Key("0x" + hex.EncodeToString(custodian.Bytes()))
+	if err != nil || !parsed.Equal(custodian) {
+		t.Errorf("Failed to parse X25519 private key: %v", err)
+	}
+	if _, err := ParseX25519Key(strings.Repeat("ab", 33)); !errors.Is(err, ErrInvalidCustodianKey) {
+		t.Errorf("Expected ErrInvalidCustodianKey, got %v", err)
+	}
+}
+
+func newX25519Key(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("Failed to generate X25519 key:", err)
+	}
+	return privateKey
+}
+
+func TestValidatePassword(t *testing.T) {
+	if err := ValidatePassword("Passw0rd!"); err != nil {
+		t.Errorf("Strong password rejected: %v", err)
+	}
+
+	var policyErr *PasswordPolicyError
+	if err := ValidatePassword("password"); !errors.As(err, &policyErr) {
+		t.Fatalf("Expected PasswordPolicyError, got %v", err)
+	}
+	if len(policyErr.Missing) != 3 {
+		t.Errorf("Expected 3 missing requirements, got %v", policyErr.Missing)
+	}
+}
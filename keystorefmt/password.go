@@ -0,0 +1,52 @@
+package keystorefmt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Password policy rules, kept in sync with validatePasswordStrength in frontend/src/main.js
+var (
+	passwordUpperRe   = regexp.MustCompile(`[A-Z]`)
+	passwordLowerRe   = regexp.MustCompile(`[a-z]`)
+	passwordDigitRe   = regexp.MustCompile(`[0-9]`)
+	passwordSpecialRe = regexp.MustCompile(`[!@#$%^&*()_+\-=\[\]{};':"\\|,.<>/?]`)
+)
+
+// PasswordPolicyError lists the password requirements a password does not meet
+type PasswordPolicyError struct {
+	Missing []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("password does not meet requirements, it needs %s", strings.Join(e.Missing, ", "))
+}
+
+// ValidatePassword applies the same password policy as the frontend
+func ValidatePassword(password string) error {
+	var missing []string
+
+	if utf8.RuneCountInString(password) < 8 {
+		missing = append(missing, "at least 8 characters")
+	}
+	if !passwordUpperRe.MatchString(password) {
+		missing = append(missing, "an uppercase letter")
+	}
+	if !passwordLowerRe.MatchString(password) {
+		missing = append(missing, "a lowercase letter")
+	}
+	if !passwordDigitRe.MatchString(password) {
+		missing = append(missing, "a number")
+	}
+	if !passwordSpecialRe.MatchString(password) {
+		missing = append(missing, "a special character")
+	}
+
+	if len(missing) > 0 {
+		return &PasswordPolicyError{Missing: missing}
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package keystorefmt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+
+	"key-generator/sharing"
+)
+
+// ShareKeystore is the on-disk layout of a share keystore. The crypto section
+// uses the same scrypt or pbkdf2, AES-128-CTR and Keccak MAC scheme as V3 key
+// keystores, or ECIES or CipherX25519 for shares encrypted to the public key
+// of a custodian, who is named by Recipient.
+type ShareKeystore struct {
+	Version    int                 `json:"version"`
+	ID         string              `json:"id"`
+	Address    string              `json:"address"`
+	ShareIndex int                 `json:"shareIndex"`
+	ShareGroup int                 `json:"shareGroup,omitempty"`
+	ShareSet   *ShareSetJSON       `json:"shareSet,omitempty"`
+	Recipient  string              `json:"recipient,omitempty"`
+	Crypto     keystore.CryptoJSON `json:"crypto"`
+}
+
+// ShareSetJSON is the split metadata stored in a share keystore. Share
+// keystores written before it was introduced have no shareSet section, and
+// ones written before secret kinds existed have no kind and hold a private key.
+// Commitments are the hex encoded commitments of a verifiable split and
+// Coordinates the x-coordinates of all shares issued for the set.
+// Policy is the group policy of a nested split.
+type ShareSetJSON struct {
+	ID          string      `json:"id"`
+	Kind        string      `json:"kind,omitempty"`
+	Threshold   int         `json:"threshold"`
+	Total       int         `json:"total"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	Version     int         `json:"version"`
+	Commitments []string    `json:"commitments,omitempty"`
+	Coordinates []int       `json:"coordinates,omitempty"`
+	Policy      *PolicyJSON `json:"policy,omitempty"`
+}
+
+// PolicyJSON is a group policy stored in a share keystore. Description is
+// the readable form of the policy for holders inspecting their file.
+type PolicyJSON struct {
+	Description string      `json:"description"`
+	Threshold   int         `json:"threshold"`
+	Groups      []GroupJSON `json:"groups"`
+}
+
+// GroupJSON is one group of a group policy
+type GroupJSON struct {
+	Name      string `json:"name"`
+	Threshold int    `json:"threshold"`
+	Total     int    `json:"total"`
+}
+
+// EncryptShare encrypts a share into a share keystore with the given key
+// derivation profile
+func EncryptShare(share sharing.Share, password string, kdf KDF) ([]byte, error) {
+	cryptoStruct, err := kdf.encryptData(share.Value, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt share: %w", err)
+	}
+
+	return json.MarshalIndent(newShareKeystore(share, cryptoStruct), "", "  ")
+}
+
+// newShareKeystore builds the share keystore of an encrypted share
+func newShareKeystore(share sharing.Share, cryptoStruct keystore.CryptoJSON) *ShareKeystore {
+	shareKeystore := &ShareKeystore{
+		Version:    3,
+		ID:         newID(),
+		Address:    share.Set.Address.Hex(),
+		ShareIndex: share.Index,
+		ShareGroup: share.Group,
+		Crypto:     cryptoStruct,
+	}
+	if share.Set.ID != "" {
+		shareKeystore.ShareSet = &ShareSetJSON{
+			ID:        share.Set.ID,
+			Kind:      share.Set.SecretKind().String(),
+			Threshold: share.Set.Threshold,
+			Total:     share.Set.Total,
+			CreatedAt: share.Set.CreatedAt,
+			Version:   share.Set.Version,
+		}
+		for _, commitment := range share.Set.Commitments {
+			shareKeystore.ShareSet.Commitments = append(shareKeystore.ShareSet.Commitments, hex.EncodeToString(commitment))
+		}
+		for _, x := range share.Set.Coordinates {
+			shareKeystore.ShareSet.Coordinates = append(shareKeystore.ShareSet.Coordinates, int(x))
+		}
+		if policy := share.Set.Policy; policy != nil {
+			shareKeystore.ShareSet.Policy = &PolicyJSON{
+				Description: policy.String(),
+				Threshold:   policy.Threshold,
+			}
+			for _, group := range policy.Groups {
+				shareKeystore.ShareSet.Policy.Groups = append(shareKeystore.ShareSet.Policy.Groups,
+					GroupJSON{Name: group.Name, Threshold: group.Threshold, Total: group.Total})
+			}
+		}
+	}
+
+	return shareKeystore
+}
+
+// DecryptShare verifies the MAC of a share keystore and decrypts its share
+func DecryptShare(keystoreJSON []byte, password string) (*sharing.Share, error) {
+	shareKeystore, err := parseShareKeystore(keystoreJSON)
+	if err != nil {
+		return nil, err
+	}
+	if shareKeystore.Recipient != "" {
+		return nil, fmt.Errorf("%w: share %d is encrypted to %s", ErrCustodianShare, shareKeystore.ShareIndex, shareKeystore.Recipient)
+	}
+
+	// DecryptDataV3 verifies the MAC before decrypting
+	value, err := keystore.DecryptDataV3(shareKeystore.Crypto, password)
+	if err != nil {
+		if errors.Is(err, keystore.ErrDecrypt) {
+			return nil, fmt.Errorf("%w for share %d", ErrIncorrectPassword, shareKeystore.ShareIndex)
+		}
+		return nil, fmt.Errorf("failed to decrypt share: %w", err)
+	}
+
+	return shareFromKeystore(shareKeystore, value)
+}
+
+// parseShareKeystore parses a share keystore and checks its public fields
+func parseShareKeystore(keystoreJSON []byte) (*ShareKeystore, error) {
+	var shareKeystore ShareKeystore
+	if err := json.Unmarshal(keystoreJSON, &shareKeystore); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	if shareKeystore.Crypto.CipherText == "" {
+		return nil, fmt.Errorf("%w: no encrypted share", ErrInvalidKeystore)
+	}
+	if !common.IsHexAddress(shareKeystore.Address) {
+		return nil, fmt.Errorf("%w: invalid address %q", ErrInvalidKeystore, shareKeystore.Address)
+	}
+	if set := shareKeystore.ShareSet; set != nil && set.Version > sharing.FormatVersion {
+		return nil, fmt.Errorf("%w: %d", sharing.ErrUnsupportedVersion, set.Version)
+	}
+
+	return &shareKeystore, nil
+}
+
+// shareFromKeystore builds the share of a decrypted share keystore
+func shareFromKeystore(shareKeystore *ShareKeystore, value []byte) (*sharing.Share, error) {
+	share := &sharing.Share{
+		Index: shareKeystore.ShareIndex,
+		Group: shareKeystore.ShareGroup,
+		Set:   sharing.SetInfo{Address: common.HexToAddress(shareKeystore.Address)},
+		Value: value,
+	}
+	if set := shareKeystore.ShareSet; set != nil {
+		if set.Kind != "" {
+			kind, err := sharing.ParseSecretKind(set.Kind)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+			}
+			share.Set.Kind = kind
+		}
+		for i, commitment := range set.Commitments {
+			point, err := hex.DecodeString(commitment)
+			if err != nil {
+				return nil, fmt.Errorf("%w: commitment %d: %v", ErrInvalidKeystore, i, err)
+			}
+			share.Set.Commitments = append(share.Set.Commitments, point)
+		}
+		for _, x := range set.Coordinates {
+			if x < 1 || x > 255 {
+				return nil, fmt.Errorf("%w: invalid share coordinate %d", ErrInvalidKeystore, x)
+			}
+			share.Set.Coordinates = append(share.Set.Coordinates, byte(x))
+		}
+		if set.Policy != nil {
+			policy := &sharing.Policy{Threshold: set.Policy.Threshold}
+			for _, group := range set.Policy.Groups {
+				policy.Groups = append(policy.Groups, sharing.Group{Name: group.Name, Threshold: group.Threshold, Total: group.Total})
+			}
+			if err := policy.Validate(); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+			}
+			share.Set.Policy = policy
+		}
+		share.Set.ID = set.ID
+		share.Set.Threshold = set.Threshold
+		share.Set.Total = set.Total
+		share.Set.CreatedAt = set.CreatedAt
+		share.Set.Version = set.Version
+	}
+
+	return share, nil
+}
+
+// newID generates a random keystore identifier
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,147 @@
+package keystorefmt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+
+	"key-generator/sharing"
+)
+
+// CipherX25519 is the cipher of share keystores encrypted to a custodian's
+// X25519 public key: an ephemeral X25519 key agreement, HKDF-SHA-256 and
+// AES-256-GCM
+const CipherX25519 = "x25519-hkdf-sha256-aes-256-gcm"
+
+// x25519Info separates the keys derived for share keystores from other uses
+// of the same X25519 keys
+const x25519Info = "key-generator custodian share"
+
+// ErrInvalidCustodianKey is returned for custodian private keys that are not
+// 32-byte X25519 keys
+var ErrInvalidCustodianKey = errors.New("invalid custodian private key")
+
+// ParseX25519Key parses a hex X25519 private key of 32 bytes, with or without
+// 0x prefix
+func ParseX25519Key(privateKeyHex string) (*ecdh.PrivateKey, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(privateKeyHex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCustodianKey, err)
+	}
+	privateKey, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %d bytes, expected a 32-byte X25519 key", ErrInvalidCustodianKey, len(raw))
+	}
+	return privateKey, nil
+}
+
+// encryptShareToX25519 encrypts a share to an X25519 public key under an
+// ephemeral key, whose public key is stored in the KDF parameters
+func encryptShareToX25519(share sharing.Share, recipient *ecdh.PublicKey) ([]byte, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt share: %w", err)
+	}
+	secret, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecipient, err)
+	}
+	aead, err := x25519AEAD(secret, ephemeral.PublicKey(), recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt share: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to encrypt share: %w", err)
+	}
+
+	cryptoStruct := keystore.CryptoJSON{
+		Cipher:     CipherX25519,
+		CipherText: hex.EncodeToString(aead.Seal(nil, nonce, share.Value, nil)),
+		KDF:        "hkdf-sha256",
+		KDFParams: map[string]interface{}{
+			"ephemeralPublicKey": hex.EncodeToString(ephemeral.PublicKey().Bytes()),
+			"info":               x25519Info,
+		},
+	}
+	cryptoStruct.CipherParams.IV = hex.EncodeToString(nonce)
+	shareKeystore := newShareKeystore(share, cryptoStruct)
+	shareKeystore.Recipient = hex.EncodeToString(recipient.Bytes())
+
+	return json.MarshalIndent(shareKeystore, "", "  ")
+}
+
+// DecryptShareWithX25519 decrypts a share keystore encrypted to a custodian's
+// X25519 public key with the custodian's private key. The GCM tag is verified
+// before the share is returned.
+func DecryptShareWithX25519(keystoreJSON []byte, privateKey *ecdh.PrivateKey) (*sharing.Share, error) {
+	shareKeystore, err := parseShareKeystore(keystoreJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRecipient(shareKeystore, CipherX25519, hex.EncodeToString(privateKey.PublicKey().Bytes())); err != nil {
+		return nil, err
+	}
+
+	ephemeralHex, _ := shareKeystore.Crypto.KDFParams["ephemeralPublicKey"].(string)
+	ephemeralBytes, err := hex.DecodeString(ephemeralHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ephemeral public key: %v", ErrInvalidKeystore, err)
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ephemeral public key: %v", ErrInvalidKeystore, err)
+	}
+	nonce, err := hex.DecodeString(shareKeystore.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	ciphertext, err := hex.DecodeString(shareKeystore.Crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+
+	secret, err := privateKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ephemeral public key: %v", ErrInvalidKeystore, err)
+	}
+	aead, err := x25519AEAD(secret, ephemeral, privateKey.PublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: %d-byte nonce", ErrInvalidKeystore, len(nonce))
+	}
+	value, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt share %d: %w", shareKeystore.ShareIndex, err)
+	}
+
+	return shareFromKeystore(shareKeystore, value)
+}
+
+// x25519AEAD derives the AES-256-GCM key from an X25519 shared secret,
+// salted with the ephemeral and recipient public keys
+func x25519AEAD(secret []byte, ephemeral *ecdh.PublicKey, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	salt := append(ephemeral.Bytes(), recipient.Bytes()...)
+	key, err := hkdf.Key(sha256.New, secret, salt, x25519Info, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
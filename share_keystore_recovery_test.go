@@ -1,12 +1,23 @@
 package main
 
 import (
+	"crypto/ecdh"
+	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/hashicorp/vault/shamir"
+
+	"key-generator/keystorefmt"
+	"key-generator/sharing"
 )
 
 func TestShareKeystoreRecovery(t *testing.T) {
@@ -42,13 +53,22 @@ func TestShareKeystoreRecovery(t *testing.T) {
 	// 3. Create share keystores using app.go CreateShareKeystore function
 	fmt.Println("\n3. Creating share keystores using app.go CreateShareKeystore...")
 	shareKeystores := make([]*ShareKeystoreResult, len(shares))
+	shareSet := ShareSetResult{
+		SetID:     "0123456789abcdef0123456789abcdef",
+		Kind:      "private-key",
+		Address:   address.Hex(),
+		Threshold: 2,
+		Total:     3,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Version:   1,
+	}
 
 	for i, share := range shares {
 		// Convert share to hex string
 		shareHex := hex.EncodeToString(share)
 
 		// Use actual CreateShareKeystore function from app.go
-		shareKeystore, err := app.CreateShareKeystore(shareHex, fmt.Sprintf("password%d", i+1), i+1, address.Hex())
+		shareKeystore, err := app.CreateShareKeystore(shareHex, fmt.Sprintf("password%d", i+1), i+1, shareSet)
 		if err != nil {
 			t.Fatal("Failed to create share keystore:", err)
 		}
@@ -60,15 +80,45 @@ func TestShareKeystoreRecovery(t *testing.T) {
 	// 4. Test recovery using keystores
 	fmt.Println("\n4. Testing recovery using keystores...")
 
-	// For this test, we'll use the original shares directly since app.go's CreateShareKeystore
-	// stores the share in ciphertext field but doesn't provide decryption
+	// Decrypt share keystores using app.go DecryptShareKeystore function
 	recoveredShares := make([][]byte, 2)
 	for i := 0; i < 2; i++ {
-		// Use original shares for now since we need to implement decryption
-		recoveredShares[i] = shares[i]
+		decrypted, err := app.DecryptShareKeystore(shareKeystores[i].Keystore, fmt.Sprintf("password%d", i+1))
+		if err != nil {
+			t.Fatal("Failed to decrypt share keystore:", err)
+		}
+		if decrypted.ShareIndex != i+1 || decrypted.Address != address.Hex() {
+			t.Fatalf("Unexpected share metadata: index %d, address %s", decrypted.ShareIndex, decrypted.Address)
+		}
+		if decrypted.ShareSet == nil || !reflect.DeepEqual(*decrypted.ShareSet, shareSet) {
+			t.Fatalf("Unexpected share set metadata: %+v", decrypted.ShareSet)
+		}
+
+		recoveredShares[i], err = hex.DecodeString(decrypted.Share)
+		if err != nil {
+			t.Fatal("Failed to decode decrypted share:", err)
+		}
 		fmt.Printf("Recovered share %d: %x (length: %d)\n", i+1, recoveredShares[i], len(recoveredShares[i]))
 	}
 
+	// Wrong password must be rejected by the MAC check
+	if _, err := app.DecryptShareKeystore(shareKeystores[0].Keystore, "wrongpassword"); err == nil {
+		t.Error("❌ Wrong password was accepted!")
+	} else {
+		fmt.Println("✅ Correctly rejected wrong password:", err)
+	}
+
+	// Ciphertext must not be the raw share
+	var parsed struct {
+		Crypto keystore.CryptoJSON `json:"crypto"`
+	}
+	if err := json.Unmarshal([]byte(shareKeystores[0].Keystore), &parsed); err != nil {
+		t.Fatal("Failed to parse share keystore:", err)
+	}
+	if parsed.Crypto.CipherText == hex.EncodeToString(shares[0]) {
+		t.Error("❌ Share keystore stores the share unencrypted!")
+	}
+
 	// 5. Combine shares to recover original key
 	fmt.Println("\n5. Combining shares to recover original key...")
 	combined, err := shamir.Combine(recoveredShares)
@@ -104,7 +154,485 @@ func TestShareKeystoreRecovery(t *testing.T) {
 	}
 
 	fmt.Println("\n=== Test Complete ===")
-	fmt.Println("\nNote: This test uses the actual CreateShareKeystore function from app.go")
-	fmt.Println("The keystores are created successfully, but decryption would need to be implemented")
-	fmt.Println("to fully test the recovery process.")
+}
+
+func TestRecoverFromShareKeystores(t *testing.T) {
+	fmt.Println("=== Recover From Share Keystores Test ===")
+
+	app := NewApp()
+
+	// 1. Generate shares and encrypt them into share keystores
+	fmt.Println("1. Generating shares and share keystores...")
+	result, err := app.GenerateShamirShares("", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+
+	shareKeystores := make([]string, len(result.Shares))
+	passwords := make([]string, len(result.Shares))
+	for i, share := range result.Shares {
+		passwords[i] = fmt.Sprintf("password%d", i+1)
+		shareKeystore, err := app.CreateShareKeystore(share, passwords[i], i+1, result.ShareSet)
+		if err != nil {
+			t.Fatal("Failed to create share keystore:", err)
+		}
+		shareKeystores[i] = shareKeystore.Keystore
+	}
+
+	// 2. Recover from shares 1 and 3
+	fmt.Println("2. Recovering from share keystores 1 and 3...")
+	recovered, err := app.RecoverFromShareKeystores(
+		[]string{shareKeystores[0], shareKeystores[2]},
+		[]string{passwords[0], passwords[2]},
+		"NewPassw0rd!",
+	)
+	if err != nil {
+		t.Fatal("Failed to recover from share keystores:", err)
+	}
+	if recovered.Address != result.Address {
+		t.Fatalf("❌ Recovered address %s, expected %s", recovered.Address, result.Address)
+	}
+
+	key, err := keystore.DecryptKey([]byte(recovered.Keystore), "NewPassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to decrypt recovered keystore:", err)
+	}
+	if key.Address.Hex() != result.Address {
+		t.Fatalf("❌ Recovered keystore address %s, expected %s", key.Address.Hex(), result.Address)
+	}
+	fmt.Println("✅ SUCCESS: Recovered keystore matches original address")
+
+	// 3. Wrong password for one share must fail
+	fmt.Println("3. Testing wrong share password...")
+	_, err = app.RecoverFromShareKeystores(
+		[]string{shareKeystores[0], shareKeystores[1]},
+		[]string{passwords[0], "wrongpassword"},
+		"NewPassw0rd!",
+	)
+	if err == nil {
+		t.Error("❌ Wrong share password was accepted!")
+	}
+
+	// 4. Too few shares must report how many more are needed
+	fmt.Println("4. Testing a single share...")
+	_, err = app.RecoverFromShareKeystores(shareKeystores[:1], passwords[:1], "NewPassw0rd!")
+	if err == nil || !strings.Contains(err.Error(), "need 1 more") {
+		t.Errorf("❌ Expected a need-more-shares error, got %v", err)
+	}
+
+	// 5. Shares from a different split must be rejected
+	fmt.Println("5. Testing shares from different splits...")
+	other, err := app.GenerateShamirShares("", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	otherKeystore, err := app.CreateShareKeystore(other.Shares[0], passwords[0], 1, other.ShareSet)
+	if err != nil {
+		t.Fatal("Failed to create share keystore:", err)
+	}
+	_, err = app.RecoverFromShareKeystores(
+		[]string{shareKeystores[0], otherKeystore.Keystore},
+		[]string{passwords[0], passwords[0]},
+		"NewPassw0rd!",
+	)
+	if err == nil {
+		t.Error("❌ Shares from different splits were combined!")
+	} else {
+		fmt.Println("✅ Correctly rejected mixed shares:", err)
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestRecoverMnemonicFromShareKeystores(t *testing.T) {
+	fmt.Println("=== Recover Mnemonic From Share Keystores Test ===")
+
+	app := NewApp()
+
+	// 1. Split a new mnemonic with a passphrase as entropy shares
+	fmt.Println("1. Splitting a mnemonic into share keystores...")
+	result, err := app.GenerateMnemonicShares(12, "extra words", "mnemonic-entropy", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split mnemonic:", err)
+	}
+	if result.Mnemonic == "" || result.ShareSet.Kind != "mnemonic-entropy" {
+		t.Fatalf("❌ Unexpected split result: mnemonic %q, kind %q", result.Mnemonic, result.ShareSet.Kind)
+	}
+
+	shareKeystores := make([]string, 2)
+	for i := range shareKeystores {
+		shareKeystore, err := app.CreateShareKeystore(result.Shares[i], "password", i+1, result.ShareSet)
+		if err != nil {
+			t.Fatal("Failed to create share keystore:", err)
+		}
+		shareKeystores[i] = shareKeystore.Keystore
+	}
+
+	// 2. Recover the mnemonic with its passphrase
+	fmt.Println("2. Recovering the mnemonic...")
+	recovered, err := app.RecoverSecretFromShareKeystores(shareKeystores, []string{"password", "password"}, "extra words", "NewPassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to recover mnemonic:", err)
+	}
+	if recovered.Mnemonic != result.Mnemonic || recovered.Address != result.Address {
+		t.Fatalf("❌ Recovered %q %s, expected %q %s", recovered.Mnemonic, recovered.Address, result.Mnemonic, result.Address)
+	}
+	fmt.Println("✅ SUCCESS: Recovered mnemonic matches original")
+
+	// 3. A missing passphrase derives a different account
+	fmt.Println("3. Testing a missing passphrase...")
+	if _, err := app.RecoverFromShareKeystores(shareKeystores, []string{"password", "password"}, "NewPassw0rd!"); err == nil {
+		t.Error("❌ Mnemonic shares were recovered without their passphrase!")
+	} else {
+		fmt.Println("✅ Correctly rejected missing passphrase:", err)
+	}
+
+	// 4. A missing password for the recovered keystore must fail the policy
+	fmt.Println("4. Testing a missing recovery password...")
+	var policyErr *keystorefmt.PasswordPolicyError
+	if _, err := app.RecoverSecretFromShareKeystores(shareKeystores, []string{"password", "password"}, "extra words", ""); !errors.As(err, &policyErr) {
+		t.Errorf("❌ Empty password gave %v, expected a password policy error", err)
+	} else {
+		fmt.Println("✅ Rejected empty password:", err)
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestVerifiableShareKeystores(t *testing.T) {
+	fmt.Println("=== Verifiable Share Keystores Test ===")
+
+	app := NewApp()
+
+	// 1. Split a new key into verifiable shares
+	fmt.Println("1. Generating verifiable shares...")
+	result, err := app.GenerateVerifiableShares(3, 2)
+	if err != nil {
+		t.Fatal("Failed to generate verifiable shares:", err)
+	}
+	if len(result.ShareSet.Commitments) != 2 {
+		t.Fatalf("❌ Expected 2 commitments, got %d", len(result.ShareSet.Commitments))
+	}
+
+	shareKeystores := make([]string, 2)
+	for i := range shareKeystores {
+		shareKeystore, err := app.CreateShareKeystore(result.Shares[i], "password", i+1, result.ShareSet)
+		if err != nil {
+			t.Fatal("Failed to create share keystore:", err)
+		}
+		shareKeystores[i] = shareKeystore.Keystore
+	}
+
+	// 2. Every holder verifies their share alone
+	fmt.Println("2. Verifying each share keystore...")
+	for i, shareKeystore := range shareKeystores {
+		verified, err := app.VerifyShareKeystore(shareKeystore, "password")
+		if err != nil {
+			t.Fatalf("❌ Share %d failed verification: %v", i+1, err)
+		}
+		if verified.Address != result.Address {
+			t.Errorf("❌ Share %d is for %s, expected %s", i+1, verified.Address, result.Address)
+		}
+	}
+	fmt.Println("✅ All shares match the commitments")
+
+	// 3. A share the dealer got wrong is caught before recovery
+	fmt.Println("3. Testing a bad share...")
+	badShare := "00" + result.Shares[2][2:]
+	badKeystore, err := app.CreateShareKeystore(badShare, "password", 3, result.ShareSet)
+	if err != nil {
+		t.Fatal("Failed to create share keystore:", err)
+	}
+	if _, err := app.VerifyShareKeystore(badKeystore.Keystore, "password"); err == nil {
+		t.Error("❌ Bad share passed verification!")
+	} else {
+		fmt.Println("✅ Correctly rejected bad share:", err)
+	}
+
+	// 4. The good shares recover the key
+	fmt.Println("4. Recovering from verifiable shares...")
+	recovered, err := app.RecoverFromShareKeystores(shareKeystores, []string{"password", "password"}, "NewPassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to recover key:", err)
+	}
+	if recovered.Address != result.Address {
+		t.Fatalf("❌ Recovered %s, expected %s", recovered.Address, result.Address)
+	}
+	fmt.Println("✅ SUCCESS: Recovered keystore matches original address")
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestReshareShareKeystores(t *testing.T) {
+	fmt.Println("=== Reshare Share Keystores Test ===")
+
+	app := NewApp()
+
+	// 1. Split a key 2 of 3 and keep two share keystores
+	fmt.Println("1. Creating the original share keystores...")
+	result, err := app.GenerateShamirShares("", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	oldKeystores := make([]string, 2)
+	for i := range oldKeystores {
+		shareKeystore, err := app.CreateShareKeystore(result.Shares[i], "password", i+1, result.ShareSet)
+		if err != nil {
+			t.Fatal("Failed to create share keystore:", err)
+		}
+		oldKeystores[i] = shareKeystore.Keystore
+	}
+
+	// 2. Reshare into a 3 of 4 set without revealing the key
+	fmt.Println("2. Resharing into 3 of 4...")
+	reshared, err := app.ReshareShareKeystores(oldKeystores, []string{"password", "password"}, "", 4, 3)
+	if err != nil {
+		t.Fatal("Failed to reshare:", err)
+	}
+	if reshared.Address != result.Address || reshared.PrivateKey != "" {
+		t.Fatalf("❌ Reshared address %s (private key exposed: %t), expected %s", reshared.Address, reshared.PrivateKey != "", result.Address)
+	}
+	if reshared.ShareSet.SetID == result.ShareSet.SetID || reshared.ShareSet.Threshold != 3 || len(reshared.Shares) != 4 {
+		t.Fatalf("❌ Unexpected reshared set: %+v", reshared.ShareSet)
+	}
+
+	address, err := app.VerifyShamirShares(reshared.Shares[1:], "")
+	if err != nil || address != result.Address {
+		t.Fatalf("❌ Reshared shares recover %s (%v), expected %s", address, err, result.Address)
+	}
+	fmt.Println("✅ SUCCESS: Reshared shares recover the same address")
+
+	// 3. Old and new shares must not combine
+	fmt.Println("3. Mixing old and new shares...")
+	newKeystore, err := app.CreateShareKeystore(reshared.Shares[0], "password", 1, reshared.ShareSet)
+	if err != nil {
+		t.Fatal("Failed to create share keystore:", err)
+	}
+	_, err = app.RecoverFromShareKeystores(
+		[]string{oldKeystores[0], newKeystore.Keystore},
+		[]string{"password", "password"},
+		"NewPassw0rd!",
+	)
+	if err == nil {
+		t.Error("❌ Old and new shares were combined!")
+	} else {
+		fmt.Println("✅ Correctly rejected mixed shares:", err)
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestExtendShareKeystores(t *testing.T) {
+	fmt.Println("=== Extend Share Keystores Test ===")
+
+	app := NewApp()
+
+	// 1. Split a key 2 of 3 and keep two share keystores
+	fmt.Println("1. Creating the original share keystores...")
+	result, err := app.GenerateShamirShares("", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	keystores := make([]string, 3)
+	for i := range keystores {
+		shareKeystore, err := app.CreateShareKeystore(result.Shares[i], "password", i+1, result.ShareSet)
+		if err != nil {
+			t.Fatal("Failed to create share keystore:", err)
+		}
+		keystores[i] = shareKeystore.Keystore
+	}
+
+	// 2. Issue a fourth share from shares 1 and 2
+	fmt.Println("2. Adding a share...")
+	added, err := app.ExtendShareKeystores(keystores[:2], []string{"password", "password"}, "", 1)
+	if err != nil {
+		t.Fatal("Failed to extend shares:", err)
+	}
+	if added.Address != result.Address || added.PrivateKey != "" || len(added.Shares) != 1 || added.Indexes[0] != 4 {
+		t.Fatalf("❌ Unexpected new share: address %s, indexes %v", added.Address, added.Indexes)
+	}
+	if added.ShareSet.SetID != result.ShareSet.SetID || added.ShareSet.Total != 4 {
+		t.Fatalf("❌ Unexpected share set: %+v", added.ShareSet)
+	}
+	newKeystore, err := app.CreateShareKeystore(added.Shares[0], "password", added.Indexes[0], added.ShareSet)
+	if err != nil {
+		t.Fatal("Failed to create share keystore:", err)
+	}
+
+	// 3. The new share recovers the key with an unchanged old share
+	fmt.Println("3. Recovering with the new share and share 3...")
+	recovered, err := app.RecoverFromShareKeystores(
+		[]string{newKeystore.Keystore, keystores[2]},
+		[]string{"password", "password"},
+		"NewPassw0rd!",
+	)
+	if err != nil {
+		t.Fatal("Failed to recover key:", err)
+	}
+	if recovered.Address != result.Address {
+		t.Fatalf("❌ Recovered %s, expected %s", recovered.Address, result.Address)
+	}
+	fmt.Println("✅ SUCCESS: New share works with the existing shares")
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestPolicyShareKeystores(t *testing.T) {
+	fmt.Println("=== Group Policy Share Keystores Test ===")
+
+	app := NewApp()
+
+	// 1. Split a key as 2 of 3 executives AND 1 of 4 security officers
+	fmt.Println("1. Creating group policy share keystores...")
+	result, err := app.GeneratePolicyShares(0, []PolicyGroup{
+		{Name: "executives", Threshold: 2, Count: 3},
+		{Name: "security", Threshold: 1, Count: 4},
+	})
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	policy := result.ShareSet.Policy
+	if policy == nil || policy.Threshold != 2 || len(result.Shares) != 7 {
+		t.Fatalf("❌ Unexpected share set: %+v", result.ShareSet)
+	}
+	if !reflect.DeepEqual(result.Groups, []int{1, 1, 1, 2, 2, 2, 2}) {
+		t.Fatalf("❌ Unexpected share groups: %v", result.Groups)
+	}
+	keystores := make([]string, len(result.Shares))
+	for i := range keystores {
+		shareKeystore, err := app.CreateShareKeystore(result.Shares[i], "password", result.Indexes[i], result.ShareSet)
+		if err != nil {
+			t.Fatal("Failed to create share keystore:", err)
+		}
+		keystores[i] = shareKeystore.Keystore
+	}
+
+	// 2. Every share keystore records the policy and its group
+	fmt.Println("2. Checking the policy in a share keystore...")
+	decrypted, err := app.DecryptShareKeystore(keystores[4], "password")
+	if err != nil {
+		t.Fatal("Failed to decrypt share keystore:", err)
+	}
+	if decrypted.ShareGroup != 2 || decrypted.ShareSet.Policy == nil ||
+		decrypted.ShareSet.Policy.Description != "2 of 2 groups: executives 2 of 3, security 1 of 4" {
+		t.Fatalf("❌ Unexpected share metadata: group %d, set %+v", decrypted.ShareGroup, decrypted.ShareSet)
+	}
+	fmt.Println("✅ SUCCESS:", decrypted.ShareSet.Policy.Description)
+
+	// 3. Both executives without a security officer are not enough
+	fmt.Println("3. Recovering without the security group...")
+	_, err = app.RecoverFromShareKeystores(keystores[:3], []string{"password", "password", "password"}, "NewPassw0rd!")
+	var policyErr *sharing.PolicyError
+	if !errors.As(err, &policyErr) || !policyErr.Groups[0].Satisfied() || policyErr.Groups[1].Satisfied() {
+		t.Fatalf("❌ Expected a policy error naming the security group, got %v", err)
+	}
+	fmt.Println("✅ SUCCESS:", err)
+
+	// 4. Two executives and one security officer recover the key
+	fmt.Println("4. Recovering with 2 executives and 1 security officer...")
+	recovered, err := app.RecoverFromShareKeystores(
+		[]string{keystores[2], keystores[6], keystores[0]},
+		[]string{"password", "password", "password"},
+		"NewPassw0rd!",
+	)
+	if err != nil {
+		t.Fatal("Failed to recover key:", err)
+	}
+	if recovered.Address != result.Address {
+		t.Fatalf("❌ Recovered %s, expected %s", recovered.Address, result.Address)
+	}
+	fmt.Println("✅ SUCCESS: Policy shares recover the key")
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestCustodianShareKeystores(t *testing.T) {
+	fmt.Println("=== Custodian Share Keystores Test ===")
+
+	app := NewApp()
+
+	// 1. Two custodians with their own keys
+	fmt.Println("1. Creating custodian keys...")
+	custodians := make([]*KeyResult, 2)
+	for i := range custodians {
+		custodian, err := app.GenerateKey(fmt.Sprintf("Custodian%d!", i+1))
+		if err != nil {
+			t.Fatal("Failed to generate custodian key:", err)
+		}
+		custodians[i] = custodian
+	}
+
+	// 2. Encrypt two shares of a 2 of 3 split to the custodians
+	fmt.Println("2. Encrypting shares to the custodians...")
+	result, err := app.GenerateShamirShares("", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	custodianShares := make([]string, 2)
+	for i := range custodianShares {
+		shareKeystore, err := app.CreateCustodianShare(result.Shares[i], custodians[i].PublicKey, i+1, result.ShareSet)
+		if err != nil {
+			t.Fatal("Failed to encrypt share:", err)
+		}
+		custodianShares[i] = shareKeystore.Keystore
+	}
+	if strings.Contains(custodianShares[0], result.Shares[0]) {
+		t.Fatal("❌ Custodian share contains the plain share")
+	}
+
+	// 3. A custodian cannot open the share of the other
+	fmt.Println("3. Opening a share with the wrong custodian key...")
+	_, err = app.UnwrapCustodianShare(custodianShares[1], custodians[0].Keystore, "Custodian1!", "SharePassw0rd!")
+	if !errors.Is(err, keystorefmt.ErrWrongRecipient) {
+		t.Fatalf("❌ Expected ErrWrongRecipient, got %v", err)
+	}
+	fmt.Println("✅ SUCCESS:", err)
+	_, err = app.UnwrapCustodianShare(custodianShares[0], custodians[0].Keystore, "Custodian2!", "SharePassw0rd!")
+	if !errors.Is(err, keystorefmt.ErrIncorrectPassword) {
+		t.Fatalf("❌ Expected ErrIncorrectPassword for a wrong custodian password, got %v", err)
+	}
+
+	// 4. Each custodian unwraps their share and the shares recover the key
+	fmt.Println("4. Unwrapping and recovering...")
+	keystores := make([]string, 2)
+	for i := range keystores {
+		unwrapped, err := app.UnwrapCustodianShare(custodianShares[i], custodians[i].Keystore, fmt.Sprintf("Custodian%d!", i+1), "SharePassw0rd!")
+		if err != nil {
+			t.Fatal("Failed to unwrap share:", err)
+		}
+		keystores[i] = unwrapped.Keystore
+	}
+	recovered, err := app.RecoverFromShareKeystores(keystores, []string{"SharePassw0rd!", "SharePassw0rd!"}, "NewPassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to recover key:", err)
+	}
+	if recovered.Address != result.Address {
+		t.Fatalf("❌ Recovered %s, expected %s", recovered.Address, result.Address)
+	}
+	fmt.Println("✅ SUCCESS: Custodian shares recover the key")
+
+	// 5. The third share goes to a custodian with an X25519 key
+	fmt.Println("5. Encrypting a share to an X25519 key...")
+	x25519Key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("Failed to generate X25519 key:", err)
+	}
+	x25519Share, err := app.CreateCustodianShare(result.Shares[2], hex.EncodeToString(x25519Key.PublicKey().Bytes()), 3, result.ShareSet)
+	if err != nil {
+		t.Fatal("Failed to encrypt share:", err)
+	}
+	if _, err := app.UnwrapCustodianShare(x25519Share.Keystore, custodians[0].Keystore, "Custodian1!", "SharePassw0rd!"); !errors.Is(err, keystorefmt.ErrWrongRecipient) {
+		t.Fatalf("❌ Expected ErrWrongRecipient for a secp256k1 custodian, got %v", err)
+	}
+	unwrapped, err := app.UnwrapX25519Share(x25519Share.Keystore, hex.EncodeToString(x25519Key.Bytes()), "SharePassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to unwrap X25519 share:", err)
+	}
+	recovered, err = app.RecoverFromShareKeystores([]string{keystores[0], unwrapped.Keystore}, []string{"SharePassw0rd!", "SharePassw0rd!"}, "NewPassw0rd!")
+	if err != nil || recovered.Address != result.Address {
+		t.Fatalf("❌ X25519 share does not recover %s: %v", result.Address, err)
+	}
+	fmt.Println("✅ SUCCESS: X25519 custodian share recovers the key")
+
+	fmt.Println("\n=== Test Complete ===")
 }
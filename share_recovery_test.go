@@ -1,11 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/hashicorp/vault/shamir"
+
+	"key-generator/keystorefmt"
+	"key-generator/sharing"
 )
 
 func TestShareRecovery(t *testing.T) {
@@ -101,3 +107,128 @@ func TestShareRecovery(t *testing.T) {
 
 	fmt.Println("\n=== Test Complete ===")
 }
+
+func TestCombineShamirSharesPassword(t *testing.T) {
+	fmt.Println("=== CombineShamirShares Password Test ===")
+
+	app := NewApp()
+
+	result, err := app.GenerateShamirShares("", 3, 2)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	shares := result.Shares[:2]
+
+	// 1. Missing or weak passwords must be refused
+	fmt.Println("1. Testing missing and weak passwords...")
+	for _, password := range []string{"", "recovered_password", "Short1!"} {
+		var policyErr *keystorefmt.PasswordPolicyError
+		if _, err := app.CombineShamirShares(shares, password); !errors.As(err, &policyErr) {
+			t.Errorf("❌ Password %q gave %v, expected a password policy error", password, err)
+		} else {
+			fmt.Printf("✅ Rejected %q: %v\n", password, err)
+		}
+	}
+
+	// 2. The keystore must be encrypted with the caller's password
+	fmt.Println("2. Combining with a strong password...")
+	password := "Recovered#Key1"
+	combined, err := app.CombineShamirShares(shares, password)
+	if err != nil {
+		t.Fatal("Failed to combine shares:", err)
+	}
+
+	key, err := keystore.DecryptKey([]byte(combined.Keystore), password)
+	if err != nil {
+		t.Fatal("Failed to decrypt combined keystore:", err)
+	}
+	if key.Address.Hex() != result.Address {
+		t.Errorf("❌ Keystore address %s, expected %s", key.Address.Hex(), result.Address)
+	}
+	if _, err := keystore.DecryptKey([]byte(combined.Keystore), "recovered_password"); err == nil {
+		t.Error("❌ Keystore still opens with the fixed password!")
+	}
+
+	// 3. VerifyShamirShares reports the address without a keystore
+	fmt.Println("3. Verifying shares...")
+	address, err := app.VerifyShamirShares(shares, "")
+	if err != nil {
+		t.Fatal("Failed to verify shares:", err)
+	}
+	if address != result.Address {
+		t.Errorf("❌ Verified address %s, expected %s", address, result.Address)
+	}
+
+	// 4. A typo in a share must be reported, not turned into a wrong key
+	fmt.Println("4. Testing malformed share...")
+	typo := []string{shares[0], "zz" + shares[1][2:]}
+	if _, err := app.VerifyShamirShares(typo, ""); err == nil || !strings.Contains(err.Error(), "share 2") {
+		t.Errorf("❌ Expected an error for share 2, got %v", err)
+	} else {
+		fmt.Println("✅ Rejected malformed share:", err)
+	}
+
+	// 5. Shares written as words are accepted and typos point at the word
+	fmt.Println("5. Testing word shares...")
+	words := []string{result.ShareWords[0], result.Shares[2]}
+	address, err = app.VerifyShamirShares(words, "")
+	if err != nil {
+		t.Fatal("Failed to verify word shares:", err)
+	}
+	if address != result.Address {
+		t.Errorf("❌ Verified address %s, expected %s", address, result.Address)
+	}
+	mistyped := strings.Fields(result.ShareWords[0])
+	mistyped[3] = "qwerty"
+	typo = []string{strings.Join(mistyped, " "), result.Shares[2]}
+	if _, err := app.VerifyShamirShares(typo, ""); err == nil || !strings.Contains(err.Error(), "share 1: word 4") {
+		t.Errorf("❌ Expected an error for word 4 of share 1, got %v", err)
+	} else {
+		fmt.Println("✅ Rejected mistyped word:", err)
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}
+
+func TestShareCountLimits(t *testing.T) {
+	fmt.Println("=== Share Count Limits Test ===")
+
+	app := NewApp()
+
+	// 1. Large schemes beyond the old UI limit of 10 are supported
+	fmt.Println("1. Generating 5 of 20 shares...")
+	result, err := app.GenerateShamirShares("", 20, 5)
+	if err != nil {
+		t.Fatal("Failed to generate 5 of 20 shares:", err)
+	}
+	address, err := app.VerifyShamirShares([]string{result.Shares[19], result.Shares[2], result.Shares[8], result.Shares[14], result.Shares[5]}, "")
+	if err != nil || address != result.Address {
+		t.Fatalf("❌ 5 of 20 shares recover %s (%v), expected %s", address, err, result.Address)
+	}
+	fmt.Println("✅ 5 of 20 shares recover the address")
+
+	// 2. Invalid counts are refused by the Go API with typed errors
+	fmt.Println("2. Testing invalid share counts...")
+	tests := []struct {
+		total     int
+		threshold int
+		err       error
+	}{
+		{1, 1, sharing.ErrInvalidShareCount},
+		{256, 3, sharing.ErrInvalidShareCount},
+		{5, 1, sharing.ErrInvalidThreshold},
+		{5, 6, sharing.ErrInvalidThreshold},
+	}
+	for _, tt := range tests {
+		if _, err := app.GenerateShamirShares("", tt.total, tt.threshold); !errors.Is(err, tt.err) {
+			t.Errorf("❌ %d of %d: expected %v, got %v", tt.threshold, tt.total, tt.err, err)
+		} else {
+			fmt.Printf("✅ Rejected %d of %d: %v\n", tt.threshold, tt.total, err)
+		}
+	}
+	if _, err := app.GenerateMnemonicShares(12, "", "seed", 300, 2); !errors.Is(err, sharing.ErrInvalidShareCount) {
+		t.Errorf("❌ Mnemonic shares: expected ErrInvalidShareCount, got %v", err)
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}
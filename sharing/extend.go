@@ -0,0 +1,190 @@
+package sharing
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// ErrUnknownCoordinates is returned when new shares cannot be given
+// x-coordinates that are certain to be unused, because the share set does
+// not record the coordinates of its shares and not all of them were given
+var ErrUnknownCoordinates = errors.New("x-coordinates of the other shares are unknown")
+
+// ExtendShares issues count additional shares of an existing share set from
+// at least its threshold of shares. The existing shares stay valid and the new
+// ones are numbered after them. The new shares record the extended share count
+// and coordinates; the existing shares keep their old metadata, so the shares
+// of the latest extension should be among those given to extend the set again.
+// passphrase is only used to check the address of mnemonic entropy shares.
+func ExtendShares(shares []Share, passphrase string, count int) ([]Share, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("%w: %d new shares", ErrInvalidShareCount, count)
+	}
+
+	// Recovering first checks that the shares are enough and belong together
+	if _, _, err := RecoverSecret(shares, passphrase); err != nil {
+		return nil, err
+	}
+	if shares[0].Set.Policy != nil {
+		return nil, fmt.Errorf("%w: shares cannot be added to a group policy, reshare the set instead", ErrInvalidPolicy)
+	}
+
+	// The set metadata with the most shares is the latest extension
+	set := shares[0].Set
+	for _, share := range shares[1:] {
+		if share.Set.Total > set.Total {
+			set = share.Set
+		}
+	}
+
+	used := make(map[byte]bool)
+	for _, share := range shares {
+		for _, x := range share.Set.Coordinates {
+			used[x] = true
+		}
+		used[share.Value[len(share.Value)-1]] = true
+	}
+	if len(used) < set.Total {
+		return nil, fmt.Errorf("%w: the share set predates recorded coordinates, give all %d shares or reshare it",
+			ErrUnknownCoordinates, set.Total)
+	}
+	if set.Total+count > MaxShares {
+		return nil, fmt.Errorf("%w: cannot extend a set of %d shares by %d, at most %d shares are possible",
+			ErrInvalidShareCount, set.Total, count, MaxShares)
+	}
+
+	extended := set
+	extended.Total = set.Total + count
+	extended.Coordinates = nil
+	xs := make([]byte, count)
+	for i := range xs {
+		x, err := unusedCoordinate(used)
+		if err != nil {
+			return nil, err
+		}
+		used[x] = true
+		xs[i] = x
+	}
+	for x := range used {
+		extended.Coordinates = append(extended.Coordinates, x)
+	}
+	sort.Slice(extended.Coordinates, func(i, j int) bool { return extended.Coordinates[i] < extended.Coordinates[j] })
+
+	newShares := make([]Share, count)
+	for i, x := range xs {
+		var value []byte
+		if set.Verifiable() {
+			value = evaluateVerifiable(shares, x)
+		} else {
+			value = evaluateBytes(shares, x)
+		}
+		newShares[i] = Share{
+			Index: set.Total + i + 1,
+			Set:   extended,
+			Value: value,
+		}
+	}
+
+	return newShares, nil
+}
+
+// evaluateBytes evaluates the byte-wise GF(256) polynomials of shares at x
+func evaluateBytes(shares []Share, x byte) []byte {
+	length := len(shares[0].Value) - 1
+	xs := make([]byte, len(shares))
+	for i, share := range shares {
+		xs[i] = share.Value[length]
+	}
+
+	value := make([]byte, length+1)
+	ys := make([]byte, len(shares))
+	for b := 0; b < length; b++ {
+		for i, share := range shares {
+			ys[i] = share.Value[b]
+		}
+		value[b] = interpolateBytes(xs, ys, x)
+	}
+	value[length] = x
+
+	return value
+}
+
+// evaluateVerifiable evaluates the polynomial of verifiable shares at x
+func evaluateVerifiable(shares []Share, x byte) []byte {
+	xs := make([]*big.Int, len(shares))
+	ys := make([]*big.Int, len(shares))
+	var check []byte
+	for i, share := range shares {
+		// RecoverSecret has already checked the shares
+		ys[i], check, _, _ = splitVerifiableShare(share.Value)
+		xs[i] = big.NewInt(int64(share.Value[len(share.Value)-1]))
+	}
+
+	y := interpolateScalar(xs, ys, big.NewInt(int64(x)))
+	value := make([]byte, 0, VerifiableShareLength)
+	value = append(value, y.FillBytes(make([]byte, 32))...)
+	value = append(value, check...)
+	return append(value, x)
+}
+
+// unusedCoordinate picks a random non-zero x-coordinate that is not used yet
+func unusedCoordinate(used map[byte]bool) (byte, error) {
+	free := make([]byte, 0, 255)
+	for x := 1; x <= 255; x++ {
+		if !used[byte(x)] {
+			free = append(free, byte(x))
+		}
+	}
+
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(free))))
+	if err != nil {
+		return 0, fmt.Errorf("failed to pick an x-coordinate: %w", err)
+	}
+	return free[i.Int64()], nil
+}
+
+// interpolateBytes evaluates at x the polynomial through the points (xs, ys)
+// over GF(256) with the AES reduction polynomial, as used by the Shamir shares
+func interpolateBytes(xs, ys []byte, x byte) byte {
+	var result byte
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			basis = gfMul(basis, gfDiv(x^xs[j], xs[i]^xs[j]))
+		}
+		result ^= gfMul(ys[i], basis)
+	}
+	return result
+}
+
+// gfMul multiplies two elements of GF(256)
+func gfMul(a, b byte) byte {
+	var product byte
+	for ; b > 0; b >>= 1 {
+		if b&1 == 1 {
+			product ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1B
+		}
+	}
+	return product
+}
+
+// gfDiv divides two elements of GF(256), b must not be zero
+func gfDiv(a, b byte) byte {
+	// b^254 is the inverse of b
+	inverse := byte(1)
+	for i := 0; i < 254; i++ {
+		inverse = gfMul(inverse, b)
+	}
+	return gfMul(a, inverse)
+}
@@ -0,0 +1,108 @@
+package sharing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SecretKind identifies the kind of secret inside a share payload
+type SecretKind byte
+
+const (
+	// KindPrivateKey is a 32-byte secp256k1 private key
+	KindPrivateKey SecretKind = 1
+
+	// KindMnemonicEntropy is the 16 or 32 bytes of entropy of a BIP-39 mnemonic
+	KindMnemonicEntropy SecretKind = 2
+
+	// KindSeed is a 64-byte BIP-39 seed, with any passphrase already applied
+	KindSeed SecretKind = 3
+)
+
+// secretKindNames are the names of secret kinds in share metadata
+var secretKindNames = map[SecretKind]string{
+	KindPrivateKey:      "private-key",
+	KindMnemonicEntropy: "mnemonic-entropy",
+	KindSeed:            "seed",
+}
+
+// ErrUnknownSecretKind is returned for secret kinds this version cannot recover
+var ErrUnknownSecretKind = errors.New("unknown secret kind")
+
+// String returns the name of the secret kind used in share metadata
+func (k SecretKind) String() string {
+	if name, ok := secretKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("kind-%d", byte(k))
+}
+
+// ParseSecretKind parses a secret kind name written by String
+func ParseSecretKind(name string) (SecretKind, error) {
+	for kind, kindName := range secretKindNames {
+		if kindName == name {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("%w %q", ErrUnknownSecretKind, name)
+}
+
+// validSecret checks that a secret has the length its kind requires
+func validSecret(kind SecretKind, secret []byte) error {
+	switch kind {
+	case KindPrivateKey:
+		if len(secret) == 32 {
+			return nil
+		}
+	case KindMnemonicEntropy:
+		if len(secret) == 16 || len(secret) == 32 {
+			return nil
+		}
+	case KindSeed:
+		if len(secret) == 64 {
+			return nil
+		}
+	default:
+		return fmt.Errorf("%w %d", ErrUnknownSecretKind, byte(kind))
+	}
+	return fmt.Errorf("invalid %s length %d", kind, len(secret))
+}
+
+// checksumLength is the length of the Keccak-256 checksum appended to a payload
+const checksumLength = 4
+
+// ErrChecksumMismatch is returned when combined shares do not reproduce the
+// checksum embedded at split time. Combining fewer than the threshold of
+// shares, or shares of different splits, yields random bytes that fail it.
+var ErrChecksumMismatch = errors.New("shares do not reconstruct the secret: not enough or wrong shares")
+
+// encodePayload prefixes the secret with its kind and appends a checksum so
+// that a wrong reconstruction can be told apart from the real secret
+func encodePayload(kind SecretKind, secret []byte) []byte {
+	payload := make([]byte, 0, 1+len(secret)+checksumLength)
+	payload = append(payload, byte(kind))
+	payload = append(payload, secret...)
+	return append(payload, payloadChecksum(payload)...)
+}
+
+// decodePayload verifies the checksum of a combined payload and returns its kind and secret
+func decodePayload(payload []byte) (SecretKind, []byte, error) {
+	if len(payload) < 1+checksumLength {
+		return 0, nil, fmt.Errorf("%w: payload too short", ErrChecksumMismatch)
+	}
+
+	body := payload[:len(payload)-checksumLength]
+	if !bytes.Equal(payloadChecksum(body), payload[len(payload)-checksumLength:]) {
+		return 0, nil, ErrChecksumMismatch
+	}
+
+	return SecretKind(body[0]), body[1:], nil
+}
+
+// payloadChecksum returns the first checksumLength bytes of Keccak-256 over kind and secret
+func payloadChecksum(body []byte) []byte {
+	return crypto.Keccak256(body)[:checksumLength]
+}
@@ -0,0 +1,300 @@
+package sharing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// A group policy is a nested split. The secret payload is first split across
+// the groups, so that the policy threshold of groups recovers it, and the
+// share of every group is split again among its members. A group or policy
+// threshold of 1 hands out copies, since any single share suffices.
+
+// ErrInvalidPolicy is returned for malformed or inconsistent group policies
+var ErrInvalidPolicy = errors.New("invalid group policy")
+
+// Group is one group of a policy: Threshold of its Total members are needed
+type Group struct {
+	Name      string
+	Threshold int
+	Total     int
+}
+
+// Policy requires Threshold of its groups, each with its own member threshold
+type Policy struct {
+	Threshold int
+	Groups    []Group
+}
+
+// ParsePolicy parses groups written as NAME:T/N separated by commas, for
+// example "executives:2/3,security:1/4". groupThreshold is the number of
+// groups required; 0 requires all of them.
+func ParsePolicy(groups string, groupThreshold int) (*Policy, error) {
+	policy := &Policy{Threshold: groupThreshold}
+	for i, spec := range strings.Split(groups, ",") {
+		spec = strings.TrimSpace(spec)
+		name, counts, ok := strings.Cut(spec, ":")
+		if !ok {
+			name, counts = fmt.Sprintf("group%d", i+1), spec
+		}
+		thresholdText, totalText, ok := strings.Cut(counts, "/")
+		if !ok {
+			return nil, fmt.Errorf("%w: group %q is not NAME:T/N", ErrInvalidPolicy, spec)
+		}
+		threshold, err := strconv.Atoi(strings.TrimSpace(thresholdText))
+		if err != nil {
+			return nil, fmt.Errorf("%w: group %q: %v", ErrInvalidPolicy, spec, err)
+		}
+		total, err := strconv.Atoi(strings.TrimSpace(totalText))
+		if err != nil {
+			return nil, fmt.Errorf("%w: group %q: %v", ErrInvalidPolicy, spec, err)
+		}
+		policy.Groups = append(policy.Groups, Group{Name: strings.TrimSpace(name), Threshold: threshold, Total: total})
+	}
+	if policy.Threshold == 0 {
+		policy.Threshold = len(policy.Groups)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Validate checks the thresholds of the policy and its groups
+func (p *Policy) Validate() error {
+	if len(p.Groups) == 0 || len(p.Groups) > MaxShares {
+		return fmt.Errorf("%w: %d groups", ErrInvalidPolicy, len(p.Groups))
+	}
+	if p.Threshold < 1 || p.Threshold > len(p.Groups) {
+		return fmt.Errorf("%w: %d of %d groups", ErrInvalidThreshold, p.Threshold, len(p.Groups))
+	}
+
+	names := make(map[string]bool, len(p.Groups))
+	for _, group := range p.Groups {
+		if group.Name == "" || names[group.Name] {
+			return fmt.Errorf("%w: group names must be unique and not empty, got %q", ErrInvalidPolicy, group.Name)
+		}
+		names[group.Name] = true
+		if group.Total < 1 || group.Total > MaxShares {
+			return fmt.Errorf("%w: group %s has %d shares", ErrInvalidShareCount, group.Name, group.Total)
+		}
+		if group.Threshold < 1 || group.Threshold > group.Total {
+			return fmt.Errorf("%w: group %s needs %d of %d", ErrInvalidThreshold, group.Name, group.Threshold, group.Total)
+		}
+	}
+
+	// A policy that one share satisfies would hand out copies of the secret
+	return ValidateSplit(p.TotalShares(), p.MinShares())
+}
+
+// TotalShares returns the number of member shares of all groups
+func (p *Policy) TotalShares() int {
+	total := 0
+	for _, group := range p.Groups {
+		total += group.Total
+	}
+	return total
+}
+
+// MinShares returns the fewest member shares that satisfy the policy
+func (p *Policy) MinShares() int {
+	thresholds := make([]int, len(p.Groups))
+	for i, group := range p.Groups {
+		thresholds[i] = group.Threshold
+	}
+	sort.Ints(thresholds)
+
+	min := 0
+	for _, threshold := range thresholds[:p.Threshold] {
+		min += threshold
+	}
+	return min
+}
+
+// GroupOf returns the 1-based group of the share with the given index, as
+// numbered by SplitSecretPolicy, or 0 for an index outside the policy
+func (p *Policy) GroupOf(index int) int {
+	for g, group := range p.Groups {
+		if index >= 1 && index <= group.Total {
+			return g + 1
+		}
+		index -= group.Total
+	}
+	return 0
+}
+
+// String describes the policy, for example
+// "2 of 2 groups: executives 2 of 3, security 1 of 4"
+func (p *Policy) String() string {
+	groups := make([]string, len(p.Groups))
+	for i, group := range p.Groups {
+		groups[i] = fmt.Sprintf("%s %d of %d", group.Name, group.Threshold, group.Total)
+	}
+	return fmt.Sprintf("%d of %d groups: %s", p.Threshold, len(p.Groups), strings.Join(groups, ", "))
+}
+
+// GroupStatus reports how many shares of one group were given and are needed
+type GroupStatus struct {
+	Name string
+	Have int
+	Need int
+}
+
+// Satisfied reports whether the group has enough shares
+func (g GroupStatus) Satisfied() bool {
+	return g.Have >= g.Need
+}
+
+// PolicyError reports which groups of a policy are satisfied and which are
+// still missing shares
+type PolicyError struct {
+	Need   int // number of groups required
+	Groups []GroupStatus
+}
+
+func (e *PolicyError) Error() string {
+	satisfied := 0
+	groups := make([]string, len(e.Groups))
+	for i, group := range e.Groups {
+		if group.Satisfied() {
+			satisfied++
+			groups[i] = fmt.Sprintf("%s satisfied", group.Name)
+		} else {
+			groups[i] = fmt.Sprintf("%s has %d of %d, needs %d more", group.Name, group.Have, group.Need, group.Need-group.Have)
+		}
+	}
+	return fmt.Sprintf("group policy not satisfied: %d of %d required groups complete (%s)",
+		satisfied, e.Need, strings.Join(groups, "; "))
+}
+
+// Is makes errors.Is(err, ErrTooFewShares) match
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrTooFewShares
+}
+
+// PolicyStatus reports for every group of the policy how many of the given
+// shares belong to it
+func PolicyStatus(policy *Policy, shares []Share) []GroupStatus {
+	status := make([]GroupStatus, len(policy.Groups))
+	for i, group := range policy.Groups {
+		status[i] = GroupStatus{Name: group.Name, Need: group.Threshold}
+	}
+	for _, share := range shares {
+		if share.Group >= 1 && share.Group <= len(status) {
+			status[share.Group-1].Have++
+		}
+	}
+	return status
+}
+
+// SplitSecretPolicy splits a secret of the given kind into member shares of
+// the groups of a policy. Shares are numbered across all groups in order.
+func SplitSecretPolicy(kind SecretKind, secret []byte, address common.Address, policy *Policy) ([]Share, error) {
+	if err := validSecret(kind, secret); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	groupShares, err := splitThreshold(encodePayload(kind, secret), len(policy.Groups), policy.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	set := NewSetInfo(kind, address, policy.TotalShares(), policy.MinShares())
+	set.Policy = &Policy{Threshold: policy.Threshold, Groups: append([]Group{}, policy.Groups...)}
+
+	shares := make([]Share, 0, policy.TotalShares())
+	for g, group := range policy.Groups {
+		values, err := splitThreshold(groupShares[g], group.Total, group.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			shares = append(shares, Share{
+				Index: len(shares) + 1,
+				Group: g + 1,
+				Set:   set,
+				Value: value,
+			})
+		}
+	}
+
+	return shares, nil
+}
+
+// combinePolicy recovers the payload of a group policy from member shares
+func combinePolicy(policy *Policy, shares []Share) ([]byte, error) {
+	members := make([][][]byte, len(policy.Groups))
+	for i, share := range shares {
+		if share.Group < 1 || share.Group > len(policy.Groups) {
+			return nil, &ShareError{Position: i + 1, Err: fmt.Errorf("%w: share is in group %d of %d", ErrInvalidPolicy, share.Group, len(policy.Groups))}
+		}
+		members[share.Group-1] = append(members[share.Group-1], share.Value)
+	}
+
+	status := PolicyStatus(policy, shares)
+	var groupShares [][]byte
+	for g, group := range policy.Groups {
+		if !status[g].Satisfied() || len(groupShares) == policy.Threshold {
+			continue
+		}
+		value, err := combineThreshold(members[g], group.Threshold)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: %w", group.Name, err)
+		}
+		groupShares = append(groupShares, value)
+	}
+	if len(groupShares) < policy.Threshold {
+		return nil, &PolicyError{Need: policy.Threshold, Groups: status}
+	}
+
+	return combineThreshold(groupShares, policy.Threshold)
+}
+
+// samePolicy reports whether two sets carry the same group policy
+func samePolicy(a, b SetInfo) bool {
+	if a.Policy == nil || b.Policy == nil {
+		return a.Policy == b.Policy
+	}
+	if a.Policy.Threshold != b.Policy.Threshold || len(a.Policy.Groups) != len(b.Policy.Groups) {
+		return false
+	}
+	for i := range a.Policy.Groups {
+		if a.Policy.Groups[i] != b.Policy.Groups[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitThreshold splits a secret like Split, but a threshold of 1 hands out
+// copies of the secret with distinct x-coordinates
+func splitThreshold(secret []byte, total int, threshold int) ([][]byte, error) {
+	if threshold > 1 {
+		return Split(secret, total, threshold)
+	}
+
+	values := make([][]byte, total)
+	for i := range values {
+		values[i] = append(append([]byte{}, secret...), byte(i+1))
+	}
+	return values, nil
+}
+
+// combineThreshold combines shares of splitThreshold
+func combineThreshold(values [][]byte, threshold int) ([]byte, error) {
+	if threshold > 1 {
+		return Combine(values)
+	}
+
+	value := values[0]
+	return append([]byte{}, value[:len(value)-1]...), nil
+}
@@ -0,0 +1,94 @@
+package sharing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"key-generator/keygen"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("executives:2/3, security:1/4", 0)
+	if err != nil {
+		t.Fatal("Failed to parse policy:", err)
+	}
+	if got := policy.String(); got != "2 of 2 groups: executives 2 of 3, security 1 of 4" {
+		t.Errorf("Unexpected policy %q", got)
+	}
+	if policy.TotalShares() != 7 || policy.MinShares() != 3 {
+		t.Errorf("Expected 7 shares and at least 3, got %d and %d", policy.TotalShares(), policy.MinShares())
+	}
+	if policy.GroupOf(3) != 1 || policy.GroupOf(4) != 2 || policy.GroupOf(8) != 0 {
+		t.Errorf("Unexpected groups %d, %d, %d for shares 3, 4, 8", policy.GroupOf(3), policy.GroupOf(4), policy.GroupOf(8))
+	}
+
+	tests := []struct {
+		groups    string
+		threshold int
+		err       error
+	}{
+		{"executives:2/3,security", 0, ErrInvalidPolicy},
+		{"a:2/3,a:1/4", 0, ErrInvalidPolicy},
+		{"a:4/3,b:1/4", 0, ErrInvalidThreshold},
+		{"a:2/3,b:1/4", 3, ErrInvalidThreshold},
+		{"a:1/3,b:1/4", 1, ErrInvalidThreshold},
+		{"a:2/200,b:1/100", 0, ErrInvalidShareCount},
+	}
+	for _, test := range tests {
+		if _, err := ParsePolicy(test.groups, test.threshold); !errors.Is(err, test.err) {
+			t.Errorf("ParsePolicy(%q, %d) = %v, expected %v", test.groups, test.threshold, err, test.err)
+		}
+	}
+}
+
+func TestSplitSecretPolicy(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	policy, err := ParsePolicy("executives:2/3,security:1/4,auditors:2/2", 2)
+	if err != nil {
+		t.Fatal("Failed to parse policy:", err)
+	}
+
+	shares, err := SplitSecretPolicy(KindPrivateKey, key.Bytes(), key.Address, policy)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	if len(shares) != 9 || shares[2].Group != 1 || shares[3].Group != 2 || shares[8].Group != 3 {
+		t.Fatalf("Unexpected shares %+v", shares)
+	}
+
+	// Any two complete groups recover the key
+	for _, combination := range [][]Share{
+		{shares[0], shares[2], shares[5]},
+		{shares[6], shares[7], shares[8]},
+		{shares[1], shares[7], shares[8], shares[2]},
+	} {
+		recovered, err := RecoverKey(combination)
+		if err != nil {
+			t.Errorf("Failed to recover key: %v", err)
+		} else if recovered.Address != key.Address {
+			t.Errorf("Recovered %s, expected %s", recovered.Address.Hex(), key.Address.Hex())
+		}
+	}
+
+	// Too few shares report which groups are satisfied and which are missing
+	_, err = RecoverKey([]Share{shares[0], shares[1], shares[7]})
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || !errors.Is(err, ErrTooFewShares) {
+		t.Fatalf("Expected PolicyError, got %v", err)
+	}
+	if !policyErr.Groups[0].Satisfied() || policyErr.Groups[1].Satisfied() || policyErr.Groups[2].Have != 1 {
+		t.Errorf("Unexpected group status %+v", policyErr.Groups)
+	}
+	if !strings.Contains(err.Error(), "auditors has 1 of 2, needs 1 more") {
+		t.Errorf("Unexpected error message %q", err)
+	}
+
+	// Policy shares cannot be extended
+	if _, err := ExtendShares([]Share{shares[0], shares[1], shares[3]}, "", 1); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("Expected ErrInvalidPolicy when extending, got %v", err)
+	}
+}
@@ -0,0 +1,379 @@
+// Package sharing splits keys into Shamir shares and recovers them.
+package sharing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hashicorp/vault/shamir"
+
+	"key-generator/keygen"
+)
+
+// FormatVersion is the version of the share format written with new shares.
+// Version 1 split the bare private key; version 2 splits a checksummed payload;
+// version 3 records the kind of secret, which may be a mnemonic or seed;
+// version 4 adds verifiable shares with polynomial commitments;
+// version 5 adds group policies.
+const FormatVersion = 5
+
+var (
+	// ErrTooFewShares is returned when fewer shares than required are combined
+	ErrTooFewShares = errors.New("not enough shares")
+
+	// ErrSetMismatch is returned when shares from different splits are combined
+	ErrSetMismatch = errors.New("shares belong to different share sets")
+
+	// ErrUnsupportedVersion is returned for share metadata newer than FormatVersion
+	ErrUnsupportedVersion = errors.New("unsupported share format version")
+
+	// ErrAddressMismatch is returned when shares disagree on their address or
+	// the recovered key does not derive the address recorded in the shares
+	ErrAddressMismatch = errors.New("address mismatch")
+
+	// ErrKindMismatch is returned when the recovered secret is not of the kind
+	// recorded in the shares or expected by the caller
+	ErrKindMismatch = errors.New("secret kind mismatch")
+)
+
+// NotEnoughSharesError reports how many more shares a recovery needs
+type NotEnoughSharesError struct {
+	Have int
+	Need int
+}
+
+func (e *NotEnoughSharesError) Error() string {
+	return fmt.Sprintf("not enough shares: have %d of %d, need %d more", e.Have, e.Need, e.Need-e.Have)
+}
+
+// Is makes errors.Is(err, ErrTooFewShares) match
+func (e *NotEnoughSharesError) Is(target error) bool {
+	return target == ErrTooFewShares
+}
+
+// SetInfo describes one split. Every share of the split carries a copy so a
+// holder can tell which split a share belongs to and how many are needed.
+// Shares written before set metadata existed only have Address set.
+type SetInfo struct {
+	ID        string         // random identifier of the split
+	Kind      SecretKind     // kind of secret that was split; zero for a private key
+	Address   common.Address // address of the key that was split, or of the first account of a mnemonic or seed
+	Threshold int            // number of shares needed to recover
+	Total     int            // number of shares created
+	CreatedAt time.Time      // time of the split
+	Version   int            // share format version
+
+	// Commitments are the compressed secp256k1 points committing to the
+	// polynomial of a verifiable split, one per coefficient; nil otherwise
+	Commitments [][]byte
+
+	// Coordinates are the x-coordinates of all shares issued for the set, so
+	// that shares added later get new ones; nil for sets split before they
+	// were recorded
+	Coordinates []byte
+
+	// Policy is the group policy of a nested split; nil for a single threshold
+	Policy *Policy
+}
+
+// Share is a single Shamir share of a secret
+type Share struct {
+	Index int     // 1-based position of the share in its split
+	Group int     // 1-based group of the share in a group policy; 0 otherwise
+	Set   SetInfo // metadata of the split the share belongs to
+	Value []byte  // raw Shamir share bytes
+}
+
+// Secret is a recovered secret together with its kind
+type Secret struct {
+	Kind  SecretKind
+	Value []byte
+}
+
+// Key returns the private key of a secret. For a mnemonic or seed this is
+// the key of the first Ethereum account (keygen.DefaultPath); the passphrase
+// is only used for mnemonic entropy, a seed already includes it.
+func (s *Secret) Key(passphrase string) (*keygen.Key, error) {
+	switch s.Kind {
+	case KindPrivateKey:
+		return keygen.FromBytes(s.Value)
+	case KindMnemonicEntropy:
+		mnemonic, err := s.Mnemonic()
+		if err != nil {
+			return nil, err
+		}
+		return keygen.FromMnemonic(mnemonic, passphrase)
+	case KindSeed:
+		return keygen.DeriveKey(s.Value, keygen.DefaultPath)
+	default:
+		return nil, fmt.Errorf("%w %d", ErrUnknownSecretKind, byte(s.Kind))
+	}
+}
+
+// Mnemonic returns the BIP-39 mnemonic of a mnemonic entropy secret
+func (s *Secret) Mnemonic() (string, error) {
+	if s.Kind != KindMnemonicEntropy {
+		return "", fmt.Errorf("%w: %s is not a mnemonic", ErrKindMismatch, s.Kind)
+	}
+	return keygen.MnemonicFromEntropy(s.Value)
+}
+
+// SecretKind returns the kind of secret the set protects, treating metadata written
+// before secret kinds existed as a private key
+func (s SetInfo) SecretKind() SecretKind {
+	if s.Kind == 0 {
+		return KindPrivateKey
+	}
+	return s.Kind
+}
+
+// NewSetInfo creates metadata for a new split with a random set ID
+func NewSetInfo(kind SecretKind, address common.Address, totalShares int, threshold int) SetInfo {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return SetInfo{
+		ID:        hex.EncodeToString(id),
+		Kind:      kind,
+		Address:   address,
+		Threshold: threshold,
+		Total:     totalShares,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Version:   FormatVersion,
+	}
+}
+
+// Split splits a secret into totalShares shares, any threshold of which recover it
+func Split(secret []byte, totalShares int, threshold int) ([][]byte, error) {
+	if err := ValidateSplit(totalShares, threshold); err != nil {
+		return nil, err
+	}
+
+	shares, err := shamir.Split(secret, totalShares, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Shamir shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+// Combine recovers a secret from raw Shamir shares
+func Combine(values [][]byte) ([]byte, error) {
+	if err := ValidateShares(values); err != nil {
+		return nil, err
+	}
+
+	secret, err := shamir.Combine(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to combine shares: %w", err)
+	}
+
+	return secret, nil
+}
+
+// SplitKey splits a private key into shares of a new share set
+func SplitKey(key *keygen.Key, totalShares int, threshold int) ([]Share, error) {
+	return SplitSecret(KindPrivateKey, key.Bytes(), key.Address, totalShares, threshold)
+}
+
+// SplitSecret splits a secret of the given kind into shares of a new share
+// set. address is recorded in the set so recovery can be verified; for a
+// mnemonic or seed it is the address of the first account.
+func SplitSecret(kind SecretKind, secret []byte, address common.Address, totalShares int, threshold int) ([]Share, error) {
+	if err := validSecret(kind, secret); err != nil {
+		return nil, err
+	}
+
+	values, err := Split(encodePayload(kind, secret), totalShares, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	set := NewSetInfo(kind, address, totalShares, threshold)
+	for _, value := range values {
+		set.Coordinates = append(set.Coordinates, value[len(value)-1])
+	}
+	shares := make([]Share, len(values))
+	for i, value := range values {
+		shares[i] = Share{
+			Index: i + 1,
+			Set:   set,
+			Value: value,
+		}
+	}
+
+	return shares, nil
+}
+
+// CombineKey recovers a private key from raw Shamir shares. Shares of a
+// checksummed payload and verifiable shares fail with ErrChecksumMismatch when
+// they do not reconstruct the split key; legacy bare-key shares cannot be checked.
+func CombineKey(values [][]byte) (*keygen.Key, error) {
+	for i, value := range values {
+		if len(value) != KeyShareLength && len(value) != LegacyKeyShareLength && len(value) != VerifiableShareLength {
+			return nil, &ShareError{Position: i + 1, Err: fmt.Errorf("%w: %d bytes, expected %d for a key share, %d for a verifiable share or %d for a legacy share",
+				ErrShareLength, len(value), KeyShareLength, VerifiableShareLength, LegacyKeyShareLength)}
+		}
+	}
+
+	secret, err := CombineSecret(values)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Kind != KindPrivateKey {
+		return nil, fmt.Errorf("%w: shares protect a %s, not a private key", ErrKindMismatch, secret.Kind)
+	}
+
+	return keygen.FromBytes(secret.Value)
+}
+
+// CombineSecret recovers a secret and its kind from raw Shamir shares.
+// Legacy bare-key shares are returned as an unchecked private key.
+func CombineSecret(values [][]byte) (*Secret, error) {
+	if len(values) > 0 && len(values[0]) == VerifiableShareLength {
+		if err := ValidateShares(values); err != nil {
+			return nil, err
+		}
+		key, err := combineVerifiable(values)
+		if err != nil {
+			return nil, err
+		}
+		return &Secret{Kind: KindPrivateKey, Value: key}, nil
+	}
+
+	combined, err := Combine(values)
+	if err != nil {
+		return nil, err
+	}
+	if len(values[0]) == LegacyKeyShareLength {
+		return &Secret{Kind: KindPrivateKey, Value: combined}, nil
+	}
+
+	return decodeSecret(combined)
+}
+
+// decodeSecret decodes and checks a combined secret payload
+func decodeSecret(payload []byte) (*Secret, error) {
+	kind, value, err := decodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := validSecret(kind, value); err != nil {
+		return nil, err
+	}
+
+	return &Secret{Kind: kind, Value: value}, nil
+}
+
+// RecoverKey recovers a private key from shares. All shares must come from
+// the same share set, there must be at least the set's threshold of them, and
+// the recovered key must derive the address recorded in the shares. Shares of
+// a mnemonic or seed return the key of its first account; use RecoverSecret
+// for the secret itself or for a mnemonic with a passphrase.
+func RecoverKey(shares []Share) (*keygen.Key, error) {
+	_, key, err := RecoverSecret(shares, "")
+	return key, err
+}
+
+// RecoverSecret recovers the secret of a share set and the key of its first
+// account, checking the shares like RecoverKey. Verifiable shares are also
+// checked one by one against the commitments of their set. passphrase is the BIP-39
+// passphrase of mnemonic entropy shares and is ignored for other kinds.
+func RecoverSecret(shares []Share, passphrase string) (*Secret, *keygen.Key, error) {
+	if len(shares) == 0 {
+		return nil, nil, &NotEnoughSharesError{Have: 0, Need: 2}
+	}
+
+	set := shares[0].Set
+	values := make([][]byte, len(shares))
+	for i, share := range shares {
+		if share.Set.Version > FormatVersion {
+			return nil, nil, fmt.Errorf("%w: share %d has version %d", ErrUnsupportedVersion, i+1, share.Set.Version)
+		}
+		if share.Set.ID != set.ID {
+			return nil, nil, fmt.Errorf("%w: share %d is from set %q, expected %q",
+				ErrSetMismatch, i+1, share.Set.ID, set.ID)
+		}
+		if share.Set.Address != set.Address {
+			return nil, nil, fmt.Errorf("%w: share %d belongs to %s, expected %s",
+				ErrAddressMismatch, i+1, share.Set.Address.Hex(), set.Address.Hex())
+		}
+		if !sameCommitments(share.Set, set) {
+			return nil, nil, fmt.Errorf("%w: share %d carries different commitments than share 1", ErrSetMismatch, i+1)
+		}
+		if !samePolicy(share.Set, set) {
+			return nil, nil, fmt.Errorf("%w: share %d carries a different group policy than share 1", ErrSetMismatch, i+1)
+		}
+		values[i] = share.Value
+	}
+
+	// Verifiable shares are each checked against the commitments first, so a
+	// bad share is named instead of only failing the recovery as a whole
+	if set.Verifiable() {
+		for i, share := range shares {
+			if err := VerifyShare(share); err != nil {
+				return nil, nil, &ShareError{Position: i + 1, Err: err}
+			}
+		}
+	}
+
+	var secret *Secret
+	if set.Policy != nil {
+		payload, err := combinePolicy(set.Policy, shares)
+		if err != nil {
+			return nil, nil, err
+		}
+		if secret, err = decodeSecret(payload); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if set.Threshold > 0 && len(shares) < set.Threshold {
+			return nil, nil, &NotEnoughSharesError{Have: len(shares), Need: set.Threshold}
+		}
+		var err error
+		if secret, err = CombineSecret(values); err != nil {
+			return nil, nil, err
+		}
+	}
+	if secret.Kind != set.SecretKind() {
+		return nil, nil, fmt.Errorf("%w: shares recover a %s, metadata records a %s",
+			ErrKindMismatch, secret.Kind, set.SecretKind())
+	}
+
+	key, err := secret.Key(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key.Address != set.Address {
+		if secret.Kind == KindMnemonicEntropy {
+			return nil, nil, fmt.Errorf("%w: recovered address %s does not match share address %s, check the BIP-39 passphrase",
+				ErrAddressMismatch, key.Address.Hex(), set.Address.Hex())
+		}
+		return nil, nil, fmt.Errorf("%w: recovered address %s does not match share address %s",
+			ErrAddressMismatch, key.Address.Hex(), set.Address.Hex())
+	}
+
+	return secret, key, nil
+}
+
+// Reshare recovers the secret of a share set in memory and splits it again
+// into a new share set with its own set ID, threshold and share count. The
+// secret and address stay the same while the old shares cannot be combined
+// with the new ones. Verifiable sets are reshared as verifiable sets; sets
+// with a group policy are reshared into a single threshold.
+// passphrase is only used to check the address of mnemonic entropy shares.
+func Reshare(shares []Share, passphrase string, totalShares int, threshold int) ([]Share, error) {
+	secret, key, err := RecoverSecret(shares, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if shares[0].Set.Verifiable() {
+		return SplitKeyVerifiable(key, totalShares, threshold)
+	}
+	return SplitSecret(secret.Kind, secret.Value, shares[0].Set.Address, totalShares, threshold)
+}
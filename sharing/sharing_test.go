@@ -0,0 +1,305 @@
+package sharing
+
+import (
+	"errors"
+	"testing"
+
+	"key-generator/keygen"
+)
+
+func TestSplitAndRecoverKey(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	shares, err := SplitKey(key, 5, 3)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("Expected 5 shares, got %d", len(shares))
+	}
+
+	recovered, err := RecoverKey([]Share{shares[4], shares[0], shares[2]})
+	if err != nil {
+		t.Fatal("Failed to recover key:", err)
+	}
+	if recovered.Address != key.Address {
+		t.Errorf("Recovered address %s, expected %s", recovered.Address.Hex(), key.Address.Hex())
+	}
+
+	var notEnough *NotEnoughSharesError
+	if _, err := RecoverKey(shares[:2]); !errors.As(err, &notEnough) || notEnough.Need != 3 {
+		t.Errorf("Expected NotEnoughSharesError needing 3, got %v", err)
+	}
+	if _, err := RecoverKey(shares[:1]); !errors.Is(err, ErrTooFewShares) {
+		t.Errorf("Expected ErrTooFewShares, got %v", err)
+	}
+}
+
+func TestSplitAndRecoverMnemonic(t *testing.T) {
+	mnemonic, err := keygen.NewMnemonic(24)
+	if err != nil {
+		t.Fatal("Failed to generate mnemonic:", err)
+	}
+	entropy, err := keygen.MnemonicEntropy(mnemonic)
+	if err != nil {
+		t.Fatal("Failed to decode mnemonic:", err)
+	}
+	key, err := keygen.FromMnemonic(mnemonic, "passphrase")
+	if err != nil {
+		t.Fatal("Failed to derive key:", err)
+	}
+
+	shares, err := SplitSecret(KindMnemonicEntropy, entropy, key.Address, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split mnemonic:", err)
+	}
+	if shares[0].Set.Kind != KindMnemonicEntropy {
+		t.Errorf("Share set kind %s, expected %s", shares[0].Set.Kind, KindMnemonicEntropy)
+	}
+
+	secret, recovered, err := RecoverSecret(shares[1:], "passphrase")
+	if err != nil {
+		t.Fatal("Failed to recover mnemonic:", err)
+	}
+	if recovered.Address != key.Address {
+		t.Errorf("Recovered address %s, expected %s", recovered.Address.Hex(), key.Address.Hex())
+	}
+	if recoveredMnemonic, err := secret.Mnemonic(); err != nil || recoveredMnemonic != mnemonic {
+		t.Errorf("Recovered mnemonic %q (%v), expected %q", recoveredMnemonic, err, mnemonic)
+	}
+
+	// The passphrase is needed to verify the address of a mnemonic
+	if _, _, err := RecoverSecret(shares[1:], ""); !errors.Is(err, ErrAddressMismatch) {
+		t.Errorf("Expected ErrAddressMismatch without passphrase, got %v", err)
+	}
+
+	// Shares of a mnemonic do not combine into a bare private key
+	if _, err := CombineKey([][]byte{shares[0].Value, shares[2].Value}); !errors.Is(err, ErrKindMismatch) {
+		t.Errorf("Expected ErrKindMismatch, got %v", err)
+	}
+
+	// Seeds recover the key of the first account without a passphrase
+	seed, err := keygen.MnemonicSeed(mnemonic, "passphrase")
+	if err != nil {
+		t.Fatal("Failed to derive seed:", err)
+	}
+	shares, err = SplitSecret(KindSeed, seed, key.Address, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split seed:", err)
+	}
+	recovered, err = RecoverKey(shares[:2])
+	if err != nil {
+		t.Fatal("Failed to recover seed:", err)
+	}
+	if recovered.Address != key.Address {
+		t.Errorf("Recovered address %s, expected %s", recovered.Address.Hex(), key.Address.Hex())
+	}
+
+	if _, err := SplitSecret(KindSeed, entropy, key.Address, 3, 2); err == nil {
+		t.Error("Expected an error splitting entropy as a seed")
+	}
+}
+
+func TestRecoverKeyMismatch(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	other, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	shares, err := SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	otherShares, err := SplitKey(other, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+
+	// Shares from different splits
+	if _, err := RecoverKey([]Share{shares[0], otherShares[1]}); !errors.Is(err, ErrSetMismatch) {
+		t.Errorf("Expected ErrSetMismatch for mixed sets, got %v", err)
+	}
+
+	// Shares of another split relabelled with this set fail the checksum
+	relabelled := otherShares[1]
+	relabelled.Set = shares[0].Set
+	if _, err := RecoverKey([]Share{shares[0], relabelled}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch for mixed shares, got %v", err)
+	}
+
+	// Shares labelled with a different address recover a key that does not match it
+	mislabelled := []Share{otherShares[0], otherShares[1]}
+	for i := range mislabelled {
+		mislabelled[i].Set.Address = key.Address
+	}
+	if _, err := RecoverKey(mislabelled); !errors.Is(err, ErrAddressMismatch) {
+		t.Errorf("Expected ErrAddressMismatch for wrong recovered key, got %v", err)
+	}
+}
+
+func TestCombineKeyChecksum(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	shares, err := SplitKey(key, 5, 3)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	if len(shares[0].Value) != KeyShareLength {
+		t.Fatalf("Expected %d byte shares, got %d", KeyShareLength, len(shares[0].Value))
+	}
+
+	// Below the threshold Combine yields random bytes; the checksum must catch them
+	if _, err := CombineKey([][]byte{shares[0].Value, shares[3].Value}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch below threshold, got %v", err)
+	}
+
+	recovered, err := CombineKey([][]byte{shares[0].Value, shares[3].Value, shares[4].Value})
+	if err != nil {
+		t.Fatal("Failed to combine shares:", err)
+	}
+	if recovered.Address != key.Address {
+		t.Errorf("Recovered address %s, expected %s", recovered.Address.Hex(), key.Address.Hex())
+	}
+
+	// Legacy shares of the bare key still combine
+	legacy, err := Split(key.Bytes(), 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	recovered, err = CombineKey(legacy[1:])
+	if err != nil {
+		t.Fatal("Failed to combine legacy shares:", err)
+	}
+	if recovered.Address != key.Address {
+		t.Errorf("Recovered legacy address %s, expected %s", recovered.Address.Hex(), key.Address.Hex())
+	}
+}
+
+func TestReshare(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	for _, verifiable := range []bool{false, true} {
+		split := SplitKey
+		if verifiable {
+			split = SplitKeyVerifiable
+		}
+		shares, err := split(key, 3, 2)
+		if err != nil {
+			t.Fatal("Failed to split key:", err)
+		}
+
+		reshared, err := Reshare([]Share{shares[2], shares[0]}, "", 5, 3)
+		if err != nil {
+			t.Fatal("Failed to reshare:", err)
+		}
+		set := reshared[0].Set
+		if len(reshared) != 5 || set.Threshold != 3 || set.ID == shares[0].Set.ID || set.Verifiable() != verifiable {
+			t.Fatalf("Unexpected reshared set %+v", set)
+		}
+
+		recovered, err := RecoverKey(reshared[2:])
+		if err != nil || recovered.Address != key.Address {
+			t.Errorf("Recovered %v (%v) from reshared shares, expected %s", recovered, err, key.Address.Hex())
+		}
+
+		// Old and new shares must not combine, with or without metadata
+		if _, err := RecoverKey([]Share{shares[0], reshared[0], reshared[1]}); !errors.Is(err, ErrSetMismatch) {
+			t.Errorf("Expected ErrSetMismatch mixing old and new shares, got %v", err)
+		}
+		if _, err := CombineKey([][]byte{shares[0].Value, reshared[0].Value, reshared[1].Value}); err == nil {
+			t.Error("Old and new raw shares were combined")
+		}
+	}
+
+	if _, err := Reshare(nil, "", 3, 2); !errors.Is(err, ErrTooFewShares) {
+		t.Errorf("Expected ErrTooFewShares, got %v", err)
+	}
+}
+
+func TestExtendShares(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	for _, verifiable := range []bool{false, true} {
+		split := SplitKey
+		if verifiable {
+			split = SplitKeyVerifiable
+		}
+		shares, err := split(key, 5, 3)
+		if err != nil {
+			t.Fatal("Failed to split key:", err)
+		}
+
+		added, err := ExtendShares([]Share{shares[4], shares[1], shares[2]}, "", 2)
+		if err != nil {
+			t.Fatal("Failed to extend shares:", err)
+		}
+		if len(added) != 2 || added[0].Index != 6 || added[1].Index != 7 || added[0].Set.Total != 7 || len(added[0].Set.Coordinates) != 7 {
+			t.Fatalf("Unexpected new shares: index %d, set %+v", added[0].Index, added[0].Set)
+		}
+		if added[0].Set.ID != shares[0].Set.ID {
+			t.Errorf("New shares are in set %q, expected %q", added[0].Set.ID, shares[0].Set.ID)
+		}
+		if verifiable {
+			if err := VerifyShare(added[1]); err != nil {
+				t.Errorf("New share failed verification: %v", err)
+			}
+		}
+
+		// New shares combine with old shares that were not used to create them
+		recovered, err := RecoverKey([]Share{added[1], shares[0], added[0]})
+		if err != nil || recovered.Address != key.Address {
+			t.Errorf("Recovered %v (%v) from new shares, expected %s", recovered, err, key.Address.Hex())
+		}
+		recovered, err = RecoverKey([]Share{shares[3], added[0], shares[0]})
+		if err != nil || recovered.Address != key.Address {
+			t.Errorf("Recovered %v (%v) from mixed shares, expected %s", recovered, err, key.Address.Hex())
+		}
+
+		// Extending again from old and new shares does not reuse a coordinate
+		more, err := ExtendShares([]Share{shares[0], shares[1], added[1]}, "", 1)
+		if err != nil {
+			t.Fatal("Failed to extend shares again:", err)
+		}
+		if more[0].Index != 8 {
+			t.Errorf("Expected share 8, got %d", more[0].Index)
+		}
+		if err := ValidateShares([][]byte{shares[0].Value, shares[1].Value, shares[2].Value, shares[3].Value, shares[4].Value, added[0].Value, added[1].Value, more[0].Value}); err != nil {
+			t.Errorf("Extended set has conflicting shares: %v", err)
+		}
+
+		if _, err := ExtendShares(shares[:2], "", 1); !errors.Is(err, ErrTooFewShares) {
+			t.Errorf("Expected ErrTooFewShares, got %v", err)
+		}
+	}
+
+	// Sets without recorded coordinates can only be extended from all shares
+	shares, err := SplitKey(key, 4, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	for i := range shares {
+		shares[i].Set.Coordinates = nil
+	}
+	if _, err := ExtendShares(shares[:3], "", 1); !errors.Is(err, ErrUnknownCoordinates) {
+		t.Errorf("Expected ErrUnknownCoordinates, got %v", err)
+	}
+	if _, err := ExtendShares(shares, "", 1); err != nil {
+		t.Errorf("Failed to extend from all shares: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package sharing
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// KeyShareLength is the length of a share of a private key payload: kind
+	// byte, 32-byte key and checksum, followed by a one-byte x-coordinate
+	KeyShareLength = 1 + 32 + checksumLength + 1
+
+	// LegacyKeyShareLength is the length of a share of a bare 32-byte private
+	// key, as written before payload checksums were introduced
+	LegacyKeyShareLength = 32 + 1
+
+	// MaxShares is the largest number of shares of one split, as every share
+	// needs its own non-zero x-coordinate in GF(256)
+	MaxShares = 255
+)
+
+var (
+	// ErrMalformedShare is returned for shares that cannot be decoded
+	ErrMalformedShare = errors.New("malformed share")
+
+	// ErrShareLength is returned for shares with an unexpected length
+	ErrShareLength = errors.New("wrong share length")
+
+	// ErrDuplicateShare is returned when two shares have the same x-coordinate
+	ErrDuplicateShare = errors.New("duplicate share")
+
+	// ErrInvalidShareCount is returned for a split into fewer than 2 or more
+	// than MaxShares shares
+	ErrInvalidShareCount = errors.New("invalid number of shares")
+
+	// ErrInvalidThreshold is returned for a threshold below 2 or above the
+	// number of shares
+	ErrInvalidThreshold = errors.New("invalid threshold")
+)
+
+// ValidateSplit checks the share count and threshold of a split
+func ValidateSplit(totalShares int, threshold int) error {
+	if totalShares < 2 || totalShares > MaxShares {
+		return fmt.Errorf("%w: %d, must be between 2 and %d", ErrInvalidShareCount, totalShares, MaxShares)
+	}
+	if threshold < 2 || threshold > totalShares {
+		return fmt.Errorf("%w: %d, must be between 2 and the number of shares %d", ErrInvalidThreshold, threshold, totalShares)
+	}
+	return nil
+}
+
+// ShareError reports a problem with one share of the input
+type ShareError struct {
+	Position int // 1-based position of the share in the input
+	Err      error
+}
+
+func (e *ShareError) Error() string {
+	return fmt.Sprintf("share %d: %v", e.Position, e.Err)
+}
+
+func (e *ShareError) Unwrap() error {
+	return e.Err
+}
+
+// ParseShares decodes shares written as hex or as words (see EncodeWords),
+// reporting the position of the first malformed one
+func ParseShares(encodedShares []string) ([][]byte, error) {
+	values := make([][]byte, len(encodedShares))
+	for i, shareHex := range encodedShares {
+		if words := strings.Fields(shareHex); len(words) > 1 {
+			value, err := DecodeWords(words)
+			if err != nil {
+				return nil, &ShareError{Position: i + 1, Err: err}
+			}
+			values[i] = value
+			continue
+		}
+
+		shareHex = strings.TrimPrefix(strings.TrimSpace(shareHex), "0x")
+		if shareHex == "" {
+			return nil, &ShareError{Position: i + 1, Err: fmt.Errorf("%w: empty", ErrMalformedShare)}
+		}
+
+		value, err := hex.DecodeString(shareHex)
+		if err != nil {
+			return nil, &ShareError{Position: i + 1, Err: fmt.Errorf("%w: %v", ErrMalformedShare, err)}
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// ValidateShares checks that raw shares can be combined: at least two of them,
+// all of the same length and no two with the same x-coordinate
+func ValidateShares(values [][]byte) error {
+	if len(values) < 2 {
+		return &NotEnoughSharesError{Have: len(values), Need: 2}
+	}
+
+	seen := make(map[byte]int, len(values))
+	for i, value := range values {
+		if len(value) < 2 {
+			return &ShareError{Position: i + 1, Err: fmt.Errorf("%w: %d bytes is too short", ErrShareLength, len(value))}
+		}
+		if len(value) != len(values[0]) {
+			return &ShareError{Position: i + 1, Err: fmt.Errorf("%w: %d bytes, share 1 has %d", ErrShareLength, len(value), len(values[0]))}
+		}
+
+		x := value[len(value)-1]
+		if j, ok := seen[x]; ok {
+			return &ShareError{Position: i + 1, Err: fmt.Errorf("%w: same x-coordinate as share %d", ErrDuplicateShare, j+1)}
+		}
+		seen[x] = i
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package sharing
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+
+	"key-generator/keygen"
+)
+
+func TestCombineKeyRejectsBadShares(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	shares, err := SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	first := hex.EncodeToString(shares[0].Value)
+	second := hex.EncodeToString(shares[1].Value)
+
+	secondWords := EncodeWords(shares[1].Value)
+	unknownWord := append([]string{}, secondWords...)
+	unknownWord[3] = "bitcoin"
+	mistypedWord := append([]string{}, secondWords...)
+	mistypedWord[5] = wordlists.English[wordIndex[secondWords[5]]^1]
+
+	tests := []struct {
+		name     string
+		shares   []string
+		position int
+		err      error
+	}{
+		{"malformed hex", []string{first, second[:10] + "zz" + second[12:]}, 2, ErrMalformedShare},
+		{"empty share", []string{"", second}, 1, ErrMalformedShare},
+		{"truncated share", []string{first, second[:len(second)-2]}, 2, ErrShareLength},
+		{"duplicate share", []string{first, second, first}, 3, ErrDuplicateShare},
+		{"same x-coordinate", []string{first, second[:4] + first[4:]}, 2, ErrDuplicateShare},
+		{"unknown word", []string{first, strings.Join(unknownWord, " ")}, 2, ErrUnknownWord},
+		{"mistyped word", []string{first, strings.Join(mistypedWord, " ")}, 2, ErrWordCheck},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := ParseShares(tt.shares)
+			if err == nil {
+				_, err = CombineKey(values)
+			}
+
+			var shareErr *ShareError
+			if !errors.As(err, &shareErr) || !errors.Is(err, tt.err) {
+				t.Fatalf("Expected ShareError wrapping %v, got %v", tt.err, err)
+			}
+			if shareErr.Position != tt.position {
+				t.Errorf("Expected position %d, got %d", tt.position, shareErr.Position)
+			}
+		})
+	}
+
+	// The length error lists every accepted share length
+	_, err = CombineKey([][]byte{shares[0].Value, shares[1].Value[:LegacyKeyShareLength-1]})
+	for _, length := range []int{KeyShareLength, VerifiableShareLength, LegacyKeyShareLength} {
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprint(length)) {
+			t.Errorf("Expected the length error to list %d, got %v", length, err)
+		}
+	}
+
+	values, err := ParseShares([]string{first})
+	if err != nil {
+		t.Fatal("Failed to parse share:", err)
+	}
+	if _, err := CombineKey(values); !errors.Is(err, ErrTooFewShares) {
+		t.Errorf("Expected ErrTooFewShares, got %v", err)
+	}
+}
+
+func TestShareWords(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	shares, err := SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+
+	// Words and hex can be mixed, and words may be shortened to 4 letters
+	words := EncodeWords(shares[2].Value)
+	if len(words) != KeyShareLength+1 {
+		t.Fatalf("Expected %d words, got %d", KeyShareLength+1, len(words))
+	}
+	short := make([]string, len(words))
+	for i, word := range words {
+		short[i] = strings.ToUpper(word[:min(len(word), wordPrefixLength)])
+	}
+
+	values, err := ParseShares([]string{hex.EncodeToString(shares[0].Value), strings.Join(short, "  ")})
+	if err != nil {
+		t.Fatal("Failed to parse shares:", err)
+	}
+	recovered, err := CombineKey(values)
+	if err != nil {
+		t.Fatal("Failed to combine shares:", err)
+	}
+	if recovered.Address != key.Address {
+		t.Errorf("Recovered address %s, expected %s", recovered.Address.Hex(), key.Address.Hex())
+	}
+
+	// A wrong checksum word is caught on the share, not only on recovery
+	words[len(words)-1] = wordlists.English[(wordIndex[words[len(words)-1]]+1)%len(wordlists.English)]
+	if _, err := DecodeWords(words); !errors.Is(err, ErrWordsChecksum) {
+		t.Errorf("Expected ErrWordsChecksum, got %v", err)
+	}
+
+	var wordErr *WordError
+	if _, err := ParseShares([]string{"abandon qwerty"}); !errors.As(err, &wordErr) || wordErr.Position != 2 {
+		t.Errorf("Expected WordError at word 2, got %v", err)
+	}
+}
+
+func TestValidateSplit(t *testing.T) {
+	tests := []struct {
+		total     int
+		threshold int
+		err       error
+	}{
+		{3, 2, nil},
+		{20, 5, nil},
+		{255, 255, nil},
+		{1, 1, ErrInvalidShareCount},
+		{256, 2, ErrInvalidShareCount},
+		{-1, 2, ErrInvalidShareCount},
+		{5, 1, ErrInvalidThreshold},
+		{5, 6, ErrInvalidThreshold},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateSplit(tt.total, tt.threshold); !errors.Is(err, tt.err) {
+			t.Errorf("ValidateSplit(%d, %d) = %v, expected %v", tt.total, tt.threshold, err, tt.err)
+		}
+	}
+
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	shares, err := SplitKey(key, 20, 5)
+	if err != nil {
+		t.Fatal("Failed to split key 5 of 20:", err)
+	}
+	recovered, err := RecoverKey([]Share{shares[19], shares[3], shares[11], shares[7], shares[0]})
+	if err != nil || recovered.Address != key.Address {
+		t.Errorf("Recovered %v (%v) from 5 of 20 shares, expected %s", recovered, err, key.Address.Hex())
+	}
+
+	if _, err := SplitKey(key, 256, 2); !errors.Is(err, ErrInvalidShareCount) {
+		t.Errorf("Expected ErrInvalidShareCount, got %v", err)
+	}
+	if _, err := SplitKeyVerifiable(key, 3, 4); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("Expected ErrInvalidThreshold, got %v", err)
+	}
+}
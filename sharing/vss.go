@@ -0,0 +1,257 @@
+package sharing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"key-generator/keygen"
+)
+
+// Verifiable shares split a private key with Feldman's scheme over the
+// secp256k1 scalar field instead of byte-wise over GF(256). The dealer
+// publishes commitments a_j*G to the polynomial coefficients, and every share
+// (x, y) satisfies y*G = sum(x^j * C_j), so a holder can check their share
+// without the others. The first commitment is the public key of the split key.
+//
+// A verifiable share is the 32-byte y, a checksum of the address and the
+// one-byte x-coordinate, so raw verifiable shares still detect a wrong
+// reconstruction even when the commitments are not at hand.
+
+// VerifiableShareLength is the length of a verifiable share
+const VerifiableShareLength = 32 + checksumLength + 1
+
+var (
+	// ErrNoCommitments is returned when a share is verified without commitments
+	ErrNoCommitments = errors.New("share set has no commitments, shares are not verifiable")
+
+	// ErrInvalidCommitments is returned for commitments that are malformed or
+	// do not belong to the address of the share set
+	ErrInvalidCommitments = errors.New("invalid share commitments")
+
+	// ErrShareCommitment is returned for a share that does not lie on the
+	// committed polynomial, usually a share handed out wrong by the dealer
+	ErrShareCommitment = errors.New("share does not match the published commitments")
+)
+
+// Verifiable reports whether the set was split with commitments
+func (s SetInfo) Verifiable() bool {
+	return len(s.Commitments) > 0
+}
+
+// SplitKeyVerifiable splits a private key into verifiable shares of a new
+// share set. The commitments are recorded in the set metadata of every share.
+func SplitKeyVerifiable(key *keygen.Key, totalShares int, threshold int) ([]Share, error) {
+	if err := ValidateSplit(totalShares, threshold); err != nil {
+		return nil, err
+	}
+
+	n := crypto.S256().Params().N
+	coefficients := make([]*big.Int, threshold)
+	coefficients[0] = new(big.Int).Set(key.PrivateKey.D)
+	for j := 1; j < threshold; j++ {
+		coefficient, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		coefficients[j] = coefficient
+	}
+
+	set := NewSetInfo(KindPrivateKey, key.Address, totalShares, threshold)
+	set.Commitments = make([][]byte, threshold)
+	for j, coefficient := range coefficients {
+		set.Commitments[j] = scalarBaseCommitment(coefficient)
+	}
+	for i := 1; i <= totalShares; i++ {
+		set.Coordinates = append(set.Coordinates, byte(i))
+	}
+
+	check := addressChecksum(key.Address)
+	shares := make([]Share, totalShares)
+	for i := range shares {
+		// Horner evaluation of the polynomial at x = i+1
+		x := big.NewInt(int64(i + 1))
+		y := new(big.Int)
+		for j := threshold - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coefficients[j])
+			y.Mod(y, n)
+		}
+
+		value := make([]byte, 0, VerifiableShareLength)
+		value = append(value, y.FillBytes(make([]byte, 32))...)
+		value = append(value, check...)
+		shares[i] = Share{
+			Index: i + 1,
+			Set:   set,
+			Value: append(value, byte(i+1)),
+		}
+	}
+
+	return shares, nil
+}
+
+// VerifyShare checks a share against the commitments of its set
+func VerifyShare(share Share) error {
+	set := share.Set
+	if !set.Verifiable() {
+		return ErrNoCommitments
+	}
+	if len(set.Commitments) != set.Threshold {
+		return fmt.Errorf("%w: %d commitments for threshold %d", ErrInvalidCommitments, len(set.Commitments), set.Threshold)
+	}
+
+	points := make([]*ecdsa.PublicKey, len(set.Commitments))
+	for j, commitment := range set.Commitments {
+		point, err := crypto.DecompressPubkey(commitment)
+		if err != nil {
+			return fmt.Errorf("%w: commitment %d: %v", ErrInvalidCommitments, j, err)
+		}
+		points[j] = point
+	}
+	if crypto.PubkeyToAddress(*points[0]) != set.Address {
+		return fmt.Errorf("%w: commitments belong to %s, not %s",
+			ErrInvalidCommitments, crypto.PubkeyToAddress(*points[0]).Hex(), set.Address.Hex())
+	}
+
+	if len(share.Value) != VerifiableShareLength {
+		return fmt.Errorf("%w: %d bytes, expected %d", ErrShareLength, len(share.Value), VerifiableShareLength)
+	}
+	y, check, x, err := splitVerifiableShare(share.Value)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(check, addressChecksum(set.Address)) {
+		return fmt.Errorf("%w: share is for a different address", ErrShareCommitment)
+	}
+
+	// sum(x^j * C_j), with x^j reduced modulo the group order
+	curve := crypto.S256()
+	n := curve.Params().N
+	power := big.NewInt(1)
+	var sumX, sumY *big.Int
+	for _, point := range points {
+		px, py := curve.ScalarMult(point.X, point.Y, power.Bytes())
+		if sumX == nil {
+			sumX, sumY = px, py
+		} else {
+			sumX, sumY = curve.Add(sumX, sumY, px, py)
+		}
+		power.Mul(power, big.NewInt(int64(x))).Mod(power, n)
+	}
+
+	gx, gy := curve.ScalarBaseMult(y.FillBytes(make([]byte, 32)))
+	if gx.Cmp(sumX) != 0 || gy.Cmp(sumY) != 0 {
+		return ErrShareCommitment
+	}
+
+	return nil
+}
+
+// sameCommitments reports whether two sets carry the same commitments
+func sameCommitments(a, b SetInfo) bool {
+	if len(a.Commitments) != len(b.Commitments) {
+		return false
+	}
+	for j := range a.Commitments {
+		if !bytes.Equal(a.Commitments[j], b.Commitments[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// combineVerifiable recovers a private key from raw verifiable shares by
+// Lagrange interpolation at zero and checks it against the address checksum
+// the shares carry. Shares must have been checked with ValidateShares.
+func combineVerifiable(values [][]byte) ([]byte, error) {
+	xs := make([]*big.Int, len(values))
+	ys := make([]*big.Int, len(values))
+	var check []byte
+	for i, value := range values {
+		y, shareCheck, x, err := splitVerifiableShare(value)
+		if err != nil {
+			return nil, &ShareError{Position: i + 1, Err: err}
+		}
+		if check != nil && !bytes.Equal(shareCheck, check) {
+			return nil, &ShareError{Position: i + 1, Err: fmt.Errorf("%w: share is for a different address than share 1", ErrChecksumMismatch)}
+		}
+		xs[i], ys[i], check = big.NewInt(int64(x)), y, shareCheck
+	}
+
+	secret := interpolateScalar(xs, ys, new(big.Int))
+	key, err := keygen.FromBytes(secret.FillBytes(make([]byte, 32)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrChecksumMismatch, err)
+	}
+	if !bytes.Equal(addressChecksum(key.Address), check) {
+		return nil, ErrChecksumMismatch
+	}
+
+	return key.Bytes(), nil
+}
+
+// interpolateScalar evaluates at x the polynomial through the points (xs, ys)
+// over the secp256k1 scalar field
+func interpolateScalar(xs, ys []*big.Int, x *big.Int) *big.Int {
+	n := crypto.S256().Params().N
+
+	result := new(big.Int)
+	for i := range xs {
+		numerator, denominator := big.NewInt(1), big.NewInt(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			numerator.Mul(numerator, new(big.Int).Sub(x, xs[j])).Mod(numerator, n)
+			denominator.Mul(denominator, new(big.Int).Sub(xs[i], xs[j])).Mod(denominator, n)
+		}
+		term := new(big.Int).ModInverse(denominator, n)
+		term.Mul(term, numerator).Mul(term, ys[i])
+		result.Add(result, term).Mod(result, n)
+	}
+
+	return result
+}
+
+// splitVerifiableShare returns the y value, address checksum and x-coordinate of a verifiable share
+func splitVerifiableShare(value []byte) (*big.Int, []byte, byte, error) {
+	y := new(big.Int).SetBytes(value[:32])
+	x := value[len(value)-1]
+	if y.Cmp(crypto.S256().Params().N) >= 0 || x == 0 {
+		return nil, nil, 0, fmt.Errorf("%w: not a verifiable share", ErrMalformedShare)
+	}
+	return y, value[32 : 32+checksumLength], x, nil
+}
+
+// addressChecksum returns the checksum of an address carried by verifiable shares
+func addressChecksum(address common.Address) []byte {
+	return payloadChecksum(address.Bytes())
+}
+
+// scalarBaseCommitment returns the compressed point a*G
+func scalarBaseCommitment(a *big.Int) []byte {
+	curve := crypto.S256()
+	x, y := curve.ScalarBaseMult(a.FillBytes(make([]byte, 32)))
+	return crypto.CompressPubkey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+}
+
+// randomScalar returns a uniformly random non-zero scalar of the secp256k1 group
+func randomScalar() (*big.Int, error) {
+	n := crypto.S256().Params().N
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate coefficient: %w", err)
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
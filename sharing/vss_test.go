@@ -0,0 +1,84 @@
+package sharing
+
+import (
+	"errors"
+	"testing"
+
+	"key-generator/keygen"
+)
+
+func TestSplitKeyVerifiable(t *testing.T) {
+	key, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+
+	shares, err := SplitKeyVerifiable(key, 5, 3)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	if len(shares[0].Set.Commitments) != 3 {
+		t.Fatalf("Expected 3 commitments, got %d", len(shares[0].Set.Commitments))
+	}
+	for _, share := range shares {
+		if err := VerifyShare(share); err != nil {
+			t.Errorf("Share %d failed verification: %v", share.Index, err)
+		}
+	}
+
+	recovered, err := RecoverKey([]Share{shares[3], shares[1], shares[4]})
+	if err != nil {
+		t.Fatal("Failed to recover key:", err)
+	}
+	if recovered.Address != key.Address {
+		t.Errorf("Recovered address %s, expected %s", recovered.Address.Hex(), key.Address.Hex())
+	}
+
+	// Raw shares combine without the commitments and still detect too few shares
+	combined, err := CombineKey([][]byte{shares[0].Value, shares[2].Value, shares[1].Value})
+	if err != nil || combined.Address != key.Address {
+		t.Errorf("Combined raw shares to %v (%v), expected %s", combined, err, key.Address.Hex())
+	}
+	if _, err := CombineKey([][]byte{shares[0].Value, shares[2].Value}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch for too few raw shares, got %v", err)
+	}
+
+	// A share handed out wrong by the dealer is caught by its holder
+	bad := shares[2]
+	bad.Value = append([]byte{}, bad.Value...)
+	bad.Value[31] ^= 1
+	if err := VerifyShare(bad); !errors.Is(err, ErrShareCommitment) {
+		t.Errorf("Expected ErrShareCommitment, got %v", err)
+	}
+	var shareErr *ShareError
+	if _, err := RecoverKey([]Share{shares[0], shares[1], bad}); !errors.As(err, &shareErr) || shareErr.Position != 3 {
+		t.Errorf("Expected ShareError for share 3, got %v", err)
+	}
+
+	// Commitments of another key are rejected
+	other, err := keygen.Generate()
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	otherShares, err := SplitKeyVerifiable(other, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	forged := shares[0]
+	forged.Set.Commitments = otherShares[0].Set.Commitments
+	forged.Set.Threshold = 2
+	if err := VerifyShare(forged); !errors.Is(err, ErrInvalidCommitments) {
+		t.Errorf("Expected ErrInvalidCommitments, got %v", err)
+	}
+	if _, err := RecoverKey([]Share{shares[0], forged, shares[2]}); !errors.Is(err, ErrSetMismatch) {
+		t.Errorf("Expected ErrSetMismatch for different commitments, got %v", err)
+	}
+
+	plain, err := SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatal("Failed to split key:", err)
+	}
+	if err := VerifyShare(plain[0]); !errors.Is(err, ErrNoCommitments) {
+		t.Errorf("Expected ErrNoCommitments, got %v", err)
+	}
+}
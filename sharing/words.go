@@ -0,0 +1,130 @@
+package sharing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+// Shares can be written as words of the BIP-39 English word list for storage
+// on paper or steel. Each word carries one byte of the share and 3 check bits
+// over that byte and its position, so most typos are caught at the word that
+// has them. A final word holds 11 bits of a checksum over the whole share.
+// Words may be shortened to their first 4 letters, which identify them.
+
+const (
+	// wordCheckBits is the number of check bits carried by each word
+	wordCheckBits = 3
+
+	// wordPrefixLength is the number of letters that identify a word
+	wordPrefixLength = 4
+)
+
+var (
+	// ErrUnknownWord is returned for words that are not in the word list
+	ErrUnknownWord = errors.New("not in the word list")
+
+	// ErrWordCheck is returned for a word whose check bits do not match,
+	// usually because it was mistyped as another valid word
+	ErrWordCheck = errors.New("check bits do not match, the word is probably mistyped")
+
+	// ErrWordsChecksum is returned when every word is valid but the share
+	// checksum does not match, for example because words were swapped
+	ErrWordsChecksum = errors.New("share word checksum mismatch, words may be swapped or missing")
+)
+
+// wordIndex and wordPrefixIndex map words and their 4-letter prefixes to word list indexes
+var wordIndex, wordPrefixIndex = func() (map[string]int, map[string]int) {
+	index := make(map[string]int, len(wordlists.English))
+	prefixes := make(map[string]int, len(wordlists.English))
+	for i, word := range wordlists.English {
+		index[word] = i
+		if len(word) >= wordPrefixLength {
+			prefixes[word[:wordPrefixLength]] = i
+		}
+	}
+	return index, prefixes
+}()
+
+// WordError reports a problem with one word of a share
+type WordError struct {
+	Position int // 1-based position of the word in the share
+	Word     string
+	Err      error
+}
+
+func (e *WordError) Error() string {
+	return fmt.Sprintf("word %d %q: %v", e.Position, e.Word, e.Err)
+}
+
+func (e *WordError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeWords encodes a raw share as words
+func EncodeWords(value []byte) []string {
+	words := make([]string, 0, len(value)+1)
+	for i, b := range value {
+		words = append(words, wordlists.English[int(b)<<wordCheckBits|wordCheck(i, b)])
+	}
+	return append(words, wordlists.English[wordsChecksum(value)])
+}
+
+// DecodeWords decodes a share written as words. Errors in single words are
+// reported as a *WordError with the position of the word.
+func DecodeWords(words []string) ([]byte, error) {
+	if len(words) < 2 {
+		return nil, fmt.Errorf("%w: %d words is too short", ErrMalformedShare, len(words))
+	}
+
+	value := make([]byte, len(words)-1)
+	indexes := make([]int, len(words))
+	for i, word := range words {
+		index, ok := lookupWord(word)
+		if !ok {
+			return nil, &WordError{Position: i + 1, Word: word, Err: ErrUnknownWord}
+		}
+		indexes[i] = index
+	}
+
+	for i, index := range indexes[:len(value)] {
+		b := byte(index >> wordCheckBits)
+		if index&(1<<wordCheckBits-1) != wordCheck(i, b) {
+			return nil, &WordError{Position: i + 1, Word: words[i], Err: ErrWordCheck}
+		}
+		value[i] = b
+	}
+
+	if indexes[len(value)] != wordsChecksum(value) {
+		return nil, ErrWordsChecksum
+	}
+
+	return value, nil
+}
+
+// lookupWord finds a word or its 4-letter prefix in the word list
+func lookupWord(word string) (int, bool) {
+	word = strings.ToLower(word)
+	if index, ok := wordIndex[word]; ok {
+		return index, true
+	}
+	if len(word) >= wordPrefixLength {
+		index, ok := wordPrefixIndex[word[:wordPrefixLength]]
+		return index, ok
+	}
+	return 0, false
+}
+
+// wordCheck returns the check bits of the byte at the given position
+func wordCheck(position int, b byte) int {
+	return int(crypto.Keccak256([]byte{byte(position >> 8), byte(position), b})[0]) & (1<<wordCheckBits - 1)
+}
+
+// wordsChecksum returns the 11-bit checksum word index of a share
+func wordsChecksum(value []byte) int {
+	hash := crypto.Keccak256(value)
+	return (int(hash[0])<<8 | int(hash[1])) & (len(wordlists.English) - 1)
+}
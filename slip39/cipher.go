@@ -0,0 +1,75 @@
+package slip39
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+)
+
+const (
+	// baseIterationCount is the total PBKDF2 iteration count at exponent 0
+	baseIterationCount = 10000
+
+	// roundCount is the number of Feistel rounds
+	roundCount = 4
+)
+
+// salt returns the encryption salt: the customization string and identifier
+// for shares that are not extendable, and nothing for extendable shares
+func salt(identifier int, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+	return append([]byte(customizationString), byte(identifier>>8), byte(identifier))
+}
+
+// roundFunction is the PBKDF2-HMAC-SHA256 round function of the Feistel network
+func roundFunction(round int, passphrase string, iterationExponent int, salt []byte, r []byte) []byte {
+	password := string(append([]byte{byte(round)}, passphrase...))
+	iterations := (baseIterationCount << iterationExponent) / roundCount
+
+	key, err := pbkdf2.Key(sha256.New, password, append(append([]byte{}, salt...), r...), iterations, len(r))
+	if err != nil {
+		// Only reachable for key lengths FIPS mode refuses
+		panic(err)
+	}
+	return key
+}
+
+// encrypt encrypts the master secret with the passphrase into the encrypted
+// master secret that is split into shares
+func encrypt(masterSecret []byte, passphrase string, iterationExponent int, identifier int, extendable bool) []byte {
+	half := len(masterSecret) / 2
+	l := append([]byte{}, masterSecret[:half]...)
+	r := append([]byte{}, masterSecret[half:]...)
+
+	s := salt(identifier, extendable)
+	for i := 0; i < roundCount; i++ {
+		f := roundFunction(i, passphrase, iterationExponent, s, r)
+		l, r = r, xor(l, f)
+	}
+	return append(r, l...)
+}
+
+// decrypt reverses encrypt. A wrong passphrase yields a different, valid
+// master secret, as SLIP-39 gives plausible deniability.
+func decrypt(encryptedMasterSecret []byte, passphrase string, iterationExponent int, identifier int, extendable bool) []byte {
+	half := len(encryptedMasterSecret) / 2
+	l := append([]byte{}, encryptedMasterSecret[:half]...)
+	r := append([]byte{}, encryptedMasterSecret[half:]...)
+
+	s := salt(identifier, extendable)
+	for i := roundCount - 1; i >= 0; i-- {
+		f := roundFunction(i, passphrase, iterationExponent, s, r)
+		l, r = r, xor(l, f)
+	}
+	return append(r, l...)
+}
+
+// xor returns a XOR b for slices of equal length
+func xor(a []byte, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package slip39
+
+const (
+	// customizationString is mixed into the checksum and the encryption salt
+	// of shares that are not extendable
+	customizationString = "shamir"
+
+	// customizationStringExtendable is mixed into the checksum of extendable shares
+	customizationStringExtendable = "shamir_extendable"
+
+	// checksumWords is the number of words of the RS1024 checksum
+	checksumWords = 3
+)
+
+// rs1024Generator is the generator of the Reed-Solomon code over GF(1024)
+var rs1024Generator = [10]uint32{
+	0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009,
+	0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120,
+}
+
+// rs1024Polymod computes the RS1024 checksum state over 10-bit values
+func rs1024Polymod(values []int) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xFFFFF)<<10 ^ uint32(v)
+		for i := 0; i < 10; i++ {
+			if (b>>i)&1 != 0 {
+				chk ^= rs1024Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// checksumCustomization returns the customization string of the checksum
+func checksumCustomization(extendable bool) string {
+	if extendable {
+		return customizationStringExtendable
+	}
+	return customizationString
+}
+
+// customizedValues prefixes data with the bytes of the customization string
+func customizedValues(customization string, data []int) []int {
+	values := make([]int, 0, len(customization)+len(data)+checksumWords)
+	for i := 0; i < len(customization); i++ {
+		values = append(values, int(customization[i]))
+	}
+	return append(values, data...)
+}
+
+// rs1024CreateChecksum returns the three checksum words for data
+func rs1024CreateChecksum(data []int, extendable bool) []int {
+	values := customizedValues(checksumCustomization(extendable), data)
+	polymod := rs1024Polymod(append(values, 0, 0, 0)) ^ 1
+
+	checksum := make([]int, checksumWords)
+	for i := range checksum {
+		checksum[i] = int(polymod>>(10*(checksumWords-1-i))) & 1023
+	}
+	return checksum
+}
+
+// rs1024VerifyChecksum reports whether data ends in a valid checksum
+func rs1024VerifyChecksum(data []int, extendable bool) bool {
+	return rs1024Polymod(customizedValues(checksumCustomization(extendable), data)) == 1
+}
@@ -0,0 +1,141 @@
+package slip39
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+const (
+	// secretIndex is the x-coordinate of the shared secret
+	secretIndex = 255
+
+	// digestIndex is the x-coordinate of the digest share
+	digestIndex = 254
+
+	// digestLength is the length of the digest that authenticates the secret
+	digestLength = 4
+)
+
+// ErrInvalidDigest is returned when shares interpolate to a secret that does
+// not match its digest, which happens for shares of different sets or too few shares
+var ErrInvalidDigest = errors.New("invalid digest of the shared secret")
+
+// point is a share of the inner Shamir scheme: an x-coordinate and the
+// y-coordinates of every byte of the secret
+type point struct {
+	x     int
+	value []byte
+}
+
+// expTable and logTable implement GF(256) with the Rijndael polynomial
+// x^8 + x^4 + x^3 + x + 1 and generator x + 1
+var expTable, logTable = func() ([255]byte, [256]byte) {
+	var exp [255]byte
+	var log [256]byte
+	poly := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(poly)
+		log[poly] = byte(i)
+		poly = (poly << 1) ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11B
+		}
+	}
+	return exp, log
+}()
+
+// interpolate evaluates at x the polynomial through the given points
+func interpolate(points []point, x int) []byte {
+	for _, p := range points {
+		if p.x == x {
+			return append([]byte{}, p.value...)
+		}
+	}
+
+	result := make([]byte, len(points[0].value))
+	for i, p := range points {
+		// log of the Lagrange basis polynomial of p evaluated at x
+		basis := 0
+		for j, other := range points {
+			if i != j {
+				basis += int(logTable[x^other.x]) - int(logTable[p.x^other.x])
+			}
+		}
+		basis = ((basis % 255) + 255) % 255
+
+		for k, y := range p.value {
+			if y != 0 {
+				result[k] ^= expTable[(int(logTable[y])+basis)%255]
+			}
+		}
+	}
+	return result
+}
+
+// createDigest authenticates a secret with the random part of the digest share
+func createDigest(randomData []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomData)
+	mac.Write(secret)
+	return mac.Sum(nil)[:digestLength]
+}
+
+// splitSecret splits a secret into count points, any threshold of which
+// recover it. The digest share lets recoverSecret detect wrong combinations.
+func splitSecret(threshold int, count int, secret []byte) ([]point, error) {
+	if threshold < 1 || threshold > count || count > maxShareCount {
+		return nil, fmt.Errorf("%w: threshold %d of %d", ErrInvalidSpec, threshold, count)
+	}
+
+	if threshold == 1 {
+		points := make([]point, count)
+		for i := range points {
+			points[i] = point{x: i, value: append([]byte{}, secret...)}
+		}
+		return points, nil
+	}
+
+	randomCount := threshold - 2
+	points := make([]point, 0, count)
+	for i := 0; i < randomCount; i++ {
+		value := make([]byte, len(secret))
+		if _, err := rand.Read(value); err != nil {
+			return nil, fmt.Errorf("failed to generate share: %w", err)
+		}
+		points = append(points, point{x: i, value: value})
+	}
+
+	randomPart := make([]byte, len(secret)-digestLength)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, fmt.Errorf("failed to generate share: %w", err)
+	}
+	digest := append(createDigest(randomPart, secret), randomPart...)
+
+	base := append(append([]point{}, points...),
+		point{x: digestIndex, value: digest},
+		point{x: secretIndex, value: secret},
+	)
+	for i := randomCount; i < count; i++ {
+		points = append(points, point{x: i, value: interpolate(base, i)})
+	}
+
+	return points, nil
+}
+
+// recoverSecret recovers a secret from threshold points and checks its digest
+func recoverSecret(threshold int, points []point) ([]byte, error) {
+	if threshold == 1 {
+		return append([]byte{}, points[0].value...), nil
+	}
+
+	secret := interpolate(points, secretIndex)
+	digestShare := interpolate(points, digestIndex)
+	if !bytes.Equal(digestShare[:digestLength], createDigest(digestShare[digestLength:], secret)) {
+		return nil, ErrInvalidDigest
+	}
+
+	return secret, nil
+}
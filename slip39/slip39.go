@@ -0,0 +1,367 @@
+// Package slip39 implements SLIP-39 Shamir backups: a master secret is
+// encrypted with an optional passphrase and split into groups of word-list
+// shares, so shares can be recovered with other SLIP-39 wallets and tools.
+package slip39
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxShareCount is the maximum number of groups and of members per group
+	maxShareCount = 16
+
+	// DefaultIterationExponent is the PBKDF2 iteration exponent used by Trezor
+	DefaultIterationExponent = 1
+
+	// maxIterationExponent is the largest exponent that fits the share format
+	maxIterationExponent = 15
+
+	// radixBits is the number of bits encoded by one word
+	radixBits = 10
+
+	// headerWords is the number of words before the share value
+	headerWords = 4
+
+	// minMnemonicWords is the length of a share of a 128-bit master secret
+	minMnemonicWords = 20
+
+	// minSecretLength is the minimum master secret length in bytes
+	minSecretLength = 16
+)
+
+var (
+	// ErrInvalidSpec is returned for group and member thresholds that cannot be shared
+	ErrInvalidSpec = errors.New("invalid SLIP-39 sharing")
+
+	// ErrInvalidSecret is returned for master secrets of unsupported length
+	ErrInvalidSecret = errors.New("master secret must be at least 128 bits and a multiple of 16 bits")
+
+	// ErrInvalidPassphrase is returned for passphrases outside printable ASCII
+	ErrInvalidPassphrase = errors.New("passphrase must consist of printable ASCII characters")
+
+	// ErrInvalidMnemonic is returned for shares with unknown words, a wrong
+	// length or bad padding
+	ErrInvalidMnemonic = errors.New("invalid SLIP-39 mnemonic")
+
+	// ErrInvalidChecksum is returned for shares whose checksum does not match
+	ErrInvalidChecksum = errors.New("invalid SLIP-39 mnemonic checksum")
+
+	// ErrShareMismatch is returned when shares of different backups are combined
+	ErrShareMismatch = errors.New("SLIP-39 shares belong to different backups")
+
+	// ErrTooFewShares is returned when there are not enough groups or members
+	ErrTooFewShares = errors.New("not enough SLIP-39 shares")
+)
+
+// GroupSpec is the member threshold and member count of one group
+type GroupSpec struct {
+	Threshold int
+	Count     int
+}
+
+// ParseGroups parses groups written as "T/N" or "TofN" separated by commas,
+// for example "1/1,2/3,3/5"
+func ParseGroups(spec string) ([]GroupSpec, error) {
+	var groups []GroupSpec
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ReplaceAll(strings.TrimSpace(part), "of", "/")
+		var group GroupSpec
+		if _, err := fmt.Sscanf(part, "%d/%d", &group.Threshold, &group.Count); err != nil {
+			return nil, fmt.Errorf("%w: group %q must look like 2/3", ErrInvalidSpec, part)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// Share is one decoded SLIP-39 mnemonic share
+type Share struct {
+	Identifier        int  // random 15-bit identifier shared by all shares of a backup
+	Extendable        bool // whether more shares can be added later with the same identifier
+	IterationExponent int  // PBKDF2 iteration exponent of the encryption
+	GroupIndex        int  // 0-based index of the share's group
+	GroupThreshold    int  // number of groups needed to recover
+	GroupCount        int  // number of groups
+	MemberIndex       int  // 0-based index of the share in its group
+	MemberThreshold   int  // number of group members needed to recover the group
+	Value             []byte
+}
+
+// Words encodes the share as a list of words
+func (s *Share) Words() []string {
+	id := s.Identifier<<5 | s.IterationExponent
+	if s.Extendable {
+		id |= 1 << 4
+	}
+	params := s.GroupIndex<<16 | (s.GroupThreshold-1)<<12 | (s.GroupCount-1)<<8 | s.MemberIndex<<4 | (s.MemberThreshold - 1)
+
+	data := []int{id >> 10, id & 1023, params >> 10, params & 1023}
+	data = append(data, bytesToWords(s.Value)...)
+	data = append(data, rs1024CreateChecksum(data, s.Extendable)...)
+
+	words := make([]string, len(data))
+	for i, index := range data {
+		words[i] = wordlist[index]
+	}
+	return words
+}
+
+// Mnemonic encodes the share as a space separated mnemonic
+func (s *Share) Mnemonic() string {
+	return strings.Join(s.Words(), " ")
+}
+
+// DecodeShare parses and verifies a mnemonic share. Words are matched
+// case-insensitively.
+func DecodeShare(mnemonic string) (*Share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < minMnemonicWords {
+		return nil, fmt.Errorf("%w: %d words, at least %d required", ErrInvalidMnemonic, len(words), minMnemonicWords)
+	}
+
+	data := make([]int, len(words))
+	for i, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %q", ErrInvalidMnemonic, word)
+		}
+		data[i] = index
+	}
+
+	paddingBits := (radixBits * (len(data) - headerWords - checksumWords)) % 16
+	if paddingBits > 8 {
+		return nil, fmt.Errorf("%w: invalid length %d", ErrInvalidMnemonic, len(words))
+	}
+
+	id := data[0]<<10 | data[1]
+	extendable := id&(1<<4) != 0
+	if !rs1024VerifyChecksum(data, extendable) {
+		return nil, ErrInvalidChecksum
+	}
+
+	params := data[2]<<10 | data[3]
+	share := &Share{
+		Identifier:        id >> 5,
+		Extendable:        extendable,
+		IterationExponent: id & 15,
+		GroupIndex:        params >> 16,
+		GroupThreshold:    (params>>12)&15 + 1,
+		GroupCount:        (params>>8)&15 + 1,
+		MemberIndex:       (params >> 4) & 15,
+		MemberThreshold:   params&15 + 1,
+	}
+	if share.GroupThreshold > share.GroupCount {
+		return nil, fmt.Errorf("%w: group threshold %d exceeds group count %d", ErrInvalidMnemonic, share.GroupThreshold, share.GroupCount)
+	}
+
+	value, err := wordsToBytes(data[headerWords:len(data)-checksumWords], paddingBits)
+	if err != nil {
+		return nil, err
+	}
+	share.Value = value
+
+	return share, nil
+}
+
+// Generate encrypts masterSecret with the passphrase and splits it into
+// groups of mnemonic shares; any groupThreshold groups, each with its member
+// threshold of shares, recover it. New backups are not extendable so that
+// tools predating the extendable flag can read them.
+func Generate(groupThreshold int, groups []GroupSpec, masterSecret []byte, passphrase string, iterationExponent int) ([][]string, error) {
+	if len(masterSecret) < minSecretLength || len(masterSecret)%2 != 0 {
+		return nil, ErrInvalidSecret
+	}
+	if err := validatePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	if iterationExponent < 0 || iterationExponent > maxIterationExponent {
+		return nil, fmt.Errorf("%w: iteration exponent %d", ErrInvalidSpec, iterationExponent)
+	}
+	if len(groups) < 1 || len(groups) > maxShareCount || groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, fmt.Errorf("%w: group threshold %d of %d groups", ErrInvalidSpec, groupThreshold, len(groups))
+	}
+	for i, group := range groups {
+		if group.Threshold == 1 && group.Count > 1 {
+			return nil, fmt.Errorf("%w: group %d shares 1 of %d, use 1 of 1 instead", ErrInvalidSpec, i+1, group.Count)
+		}
+	}
+
+	idBytes := make([]byte, 2)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate identifier: %w", err)
+	}
+	identifier := (int(idBytes[0])<<8 | int(idBytes[1])) & 0x7FFF
+
+	encrypted := encrypt(masterSecret, passphrase, iterationExponent, identifier, false)
+	groupPoints, err := splitSecret(groupThreshold, len(groups), encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonics := make([][]string, len(groups))
+	for i, group := range groups {
+		memberPoints, err := splitSecret(group.Threshold, group.Count, groupPoints[i].value)
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %w", i+1, err)
+		}
+
+		for _, member := range memberPoints {
+			share := Share{
+				Identifier:        identifier,
+				IterationExponent: iterationExponent,
+				GroupIndex:        groupPoints[i].x,
+				GroupThreshold:    groupThreshold,
+				GroupCount:        len(groups),
+				MemberIndex:       member.x,
+				MemberThreshold:   group.Threshold,
+				Value:             member.value,
+			}
+			mnemonics[i] = append(mnemonics[i], share.Mnemonic())
+		}
+	}
+
+	return mnemonics, nil
+}
+
+// Combine recovers the master secret from mnemonic shares. Shares may be
+// given in any order and duplicates are ignored. A wrong passphrase does not
+// fail but yields a different master secret.
+func Combine(mnemonics []string, passphrase string) ([]byte, error) {
+	if err := validatePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	if len(mnemonics) == 0 {
+		return nil, fmt.Errorf("%w: no shares given", ErrTooFewShares)
+	}
+
+	shares := make([]*Share, len(mnemonics))
+	for i, mnemonic := range mnemonics {
+		share, err := DecodeShare(mnemonic)
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", i+1, err)
+		}
+		shares[i] = share
+	}
+
+	first := shares[0]
+	groups := make(map[int][]*Share)
+	for i, share := range shares {
+		if share.Identifier != first.Identifier || share.Extendable != first.Extendable || share.IterationExponent != first.IterationExponent {
+			return nil, fmt.Errorf("%w: share %d does not begin with the same words as share 1", ErrShareMismatch, i+1)
+		}
+		if share.GroupThreshold != first.GroupThreshold || share.GroupCount != first.GroupCount {
+			return nil, fmt.Errorf("%w: share %d has a different group threshold or count", ErrShareMismatch, i+1)
+		}
+		if len(share.Value) != len(first.Value) {
+			return nil, fmt.Errorf("%w: share %d has a different length", ErrShareMismatch, i+1)
+		}
+
+		members, duplicate := groups[share.GroupIndex], false
+		for _, member := range members {
+			if member.MemberThreshold != share.MemberThreshold {
+				return nil, fmt.Errorf("%w: share %d has a different member threshold than its group", ErrShareMismatch, i+1)
+			}
+			if member.MemberIndex == share.MemberIndex {
+				if string(member.Value) != string(share.Value) {
+					return nil, fmt.Errorf("%w: share %d conflicts with another share of the same index", ErrShareMismatch, i+1)
+				}
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			groups[share.GroupIndex] = append(members, share)
+		}
+	}
+
+	// Recover every group that has enough members, in group index order
+	groupIndexes := make([]int, 0, len(groups))
+	for index := range groups {
+		groupIndexes = append(groupIndexes, index)
+	}
+	sort.Ints(groupIndexes)
+
+	var groupPoints []point
+	var incomplete []string
+	for _, index := range groupIndexes {
+		members := groups[index]
+		threshold := members[0].MemberThreshold
+		if len(members) < threshold {
+			incomplete = append(incomplete, fmt.Sprintf("group %d has %d of %d shares", index+1, len(members), threshold))
+			continue
+		}
+		if len(groupPoints) == first.GroupThreshold {
+			continue
+		}
+
+		memberPoints := make([]point, threshold)
+		for i, member := range members[:threshold] {
+			memberPoints[i] = point{x: member.MemberIndex, value: member.Value}
+		}
+		groupSecret, err := recoverSecret(threshold, memberPoints)
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %w", index+1, err)
+		}
+		groupPoints = append(groupPoints, point{x: index, value: groupSecret})
+	}
+
+	if len(groupPoints) < first.GroupThreshold {
+		message := fmt.Sprintf("%d of %d groups complete", len(groupPoints), first.GroupThreshold)
+		if len(incomplete) > 0 {
+			message += "; " + strings.Join(incomplete, ", ")
+		}
+		return nil, fmt.Errorf("%w: %s", ErrTooFewShares, message)
+	}
+
+	encrypted, err := recoverSecret(first.GroupThreshold, groupPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypt(encrypted, passphrase, first.IterationExponent, first.Identifier, first.Extendable), nil
+}
+
+// validatePassphrase checks that a passphrase only has printable ASCII characters
+func validatePassphrase(passphrase string) error {
+	for i := 0; i < len(passphrase); i++ {
+		if passphrase[i] < 32 || passphrase[i] > 126 {
+			return ErrInvalidPassphrase
+		}
+	}
+	return nil
+}
+
+// bytesToWords encodes bytes as 10-bit values, left padded with zero bits
+func bytesToWords(value []byte) []int {
+	wordCount := (len(value)*8 + radixBits - 1) / radixBits
+	padding := wordCount*radixBits - len(value)*8
+
+	words := make([]int, wordCount)
+	for bit := 0; bit < len(value)*8; bit++ {
+		if value[bit/8]>>(7-bit%8)&1 != 0 {
+			position := bit + padding
+			words[position/radixBits] |= 1 << (radixBits - 1 - position%radixBits)
+		}
+	}
+	return words
+}
+
+// wordsToBytes decodes 10-bit values into bytes, checking that the padding bits are zero
+func wordsToBytes(words []int, paddingBits int) ([]byte, error) {
+	value := make([]byte, (len(words)*radixBits-paddingBits)/8)
+	for position := 0; position < len(words)*radixBits; position++ {
+		if words[position/radixBits]>>(radixBits-1-position%radixBits)&1 == 0 {
+			continue
+		}
+		if position < paddingBits {
+			return nil, fmt.Errorf("%w: invalid padding", ErrInvalidMnemonic)
+		}
+		bit := position - paddingBits
+		value[bit/8] |= 1 << (7 - bit%8)
+	}
+	return value, nil
+}
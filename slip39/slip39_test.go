@@ -0,0 +1,139 @@
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCombineVectors(t *testing.T) {
+	// SLIP-39 reference test vectors, passphrase "TREZOR"
+	tests := []struct {
+		name      string
+		mnemonics []string
+		secret    string
+	}{
+		{
+			"128-bit single share",
+			[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"},
+			"bb54aac4b89dc868ba37d9cc21b2cece",
+		},
+		{
+			"128-bit 2 of 3 shares",
+			[]string{
+				"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+				"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+			},
+			"b43ceb7e57a0ea8766221624d01b0864",
+		},
+		{
+			"256-bit single share",
+			[]string{"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck"},
+			"989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := Combine(tt.mnemonics, "TREZOR")
+			if err != nil {
+				t.Fatal("Failed to combine:", err)
+			}
+			if hex.EncodeToString(secret) != tt.secret {
+				t.Errorf("Master secret %x, expected %s", secret, tt.secret)
+			}
+
+			// Decoding and re-encoding a share is lossless
+			for _, mnemonic := range tt.mnemonics {
+				share, err := DecodeShare(mnemonic)
+				if err != nil {
+					t.Fatal("Failed to decode share:", err)
+				}
+				if share.Mnemonic() != mnemonic {
+					t.Errorf("Re-encoded %q, expected %q", share.Mnemonic(), mnemonic)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeShareErrors(t *testing.T) {
+	valid := strings.Fields("duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard")
+
+	badChecksum := append([]string{}, valid...)
+	badChecksum[len(badChecksum)-1] = "kidney"
+	if _, err := DecodeShare(strings.Join(badChecksum, " ")); !errors.Is(err, ErrInvalidChecksum) {
+		t.Errorf("Expected ErrInvalidChecksum, got %v", err)
+	}
+
+	unknown := append([]string{}, valid...)
+	unknown[5] = "bitcoin"
+	if _, err := DecodeShare(strings.Join(unknown, " ")); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Errorf("Expected ErrInvalidMnemonic for an unknown word, got %v", err)
+	}
+
+	if _, err := DecodeShare(strings.Join(valid[:19], " ")); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Errorf("Expected ErrInvalidMnemonic for a short share, got %v", err)
+	}
+}
+
+func TestGenerateAndCombineGroups(t *testing.T) {
+	secret, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	groups, err := ParseGroups("1/1, 2/3, 3of5")
+	if err != nil {
+		t.Fatal("Failed to parse groups:", err)
+	}
+
+	mnemonics, err := Generate(2, groups, secret, "passphrase", 0)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	if len(mnemonics) != 3 || len(mnemonics[1]) != 3 || len(mnemonics[2]) != 5 {
+		t.Fatalf("Unexpected share layout %d/%d/%d", len(mnemonics), len(mnemonics[1]), len(mnemonics[2]))
+	}
+
+	// Group 1 alone plus two members of group 2, given in any order
+	recovered, err := Combine([]string{mnemonics[1][2], mnemonics[0][0], mnemonics[1][0]}, "passphrase")
+	if err != nil {
+		t.Fatal("Failed to combine shares:", err)
+	}
+	if !bytes.Equal(recovered, secret) {
+		t.Errorf("Recovered %x, expected %x", recovered, secret)
+	}
+
+	// Groups 2 and 3 without group 1
+	recovered, err = Combine([]string{mnemonics[1][0], mnemonics[1][1], mnemonics[2][4], mnemonics[2][0], mnemonics[2][2]}, "passphrase")
+	if err != nil || !bytes.Equal(recovered, secret) {
+		t.Errorf("Recovered %x (%v), expected %x", recovered, err, secret)
+	}
+
+	// A wrong passphrase yields a different secret
+	recovered, err = Combine([]string{mnemonics[0][0], mnemonics[1][0], mnemonics[1][1]}, "other")
+	if err != nil || bytes.Equal(recovered, secret) {
+		t.Errorf("Wrong passphrase recovered %x (%v)", recovered, err)
+	}
+
+	if _, err := Combine([]string{mnemonics[0][0], mnemonics[1][0]}, "passphrase"); !errors.Is(err, ErrTooFewShares) {
+		t.Errorf("Expected ErrTooFewShares, got %v", err)
+	}
+
+	other, err := Generate(1, []GroupSpec{{Threshold: 2, Count: 3}}, secret, "", DefaultIterationExponent)
+	if err != nil {
+		t.Fatal("Failed to generate shares:", err)
+	}
+	if _, err := Combine([]string{other[0][0], mnemonics[1][0]}, ""); !errors.Is(err, ErrShareMismatch) {
+		t.Errorf("Expected ErrShareMismatch, got %v", err)
+	}
+
+	if _, err := Generate(1, []GroupSpec{{Threshold: 1, Count: 3}}, secret, "", 0); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("Expected ErrInvalidSpec for 1 of 3, got %v", err)
+	}
+	if _, err := Generate(1, []GroupSpec{{Threshold: 2, Count: 3}}, secret[:15], "", 0); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Expected ErrInvalidSecret, got %v", err)
+	}
+	if _, err := Generate(1, []GroupSpec{{Threshold: 2, Count: 3}}, secret, "päss", 0); !errors.Is(err, ErrInvalidPassphrase) {
+		t.Errorf("Expected ErrInvalidPassphrase, got %v", err)
+	}
+}
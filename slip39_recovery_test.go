@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+func TestSLIP39Recovery(t *testing.T) {
+	fmt.Println("=== SLIP-39 Recovery Test ===")
+
+	app := NewApp()
+
+	// 1. Generate a backup that needs both a 2-of-3 and a 3-of-5 group
+	fmt.Println("1. Generating SLIP-39 shares...")
+	result, err := app.GenerateSLIP39Shares(128, "TREZOR", 2, []SLIP39Group{{Threshold: 2, Count: 3}, {Threshold: 3, Count: 5}})
+	if err != nil {
+		t.Fatal("Failed to generate SLIP-39 shares:", err)
+	}
+	if len(result.Groups) != 2 || len(result.Groups[0]) != 3 || len(result.Groups[1]) != 5 {
+		t.Fatalf("❌ Unexpected share layout: %v", result.Groups)
+	}
+	fmt.Println("Address:", result.Address)
+	fmt.Println("First share:", result.Groups[0][0])
+
+	// 2. Recover from two shares of group 1 and three of group 2
+	fmt.Println("2. Recovering from SLIP-39 shares...")
+	shares := []string{result.Groups[0][1], result.Groups[1][4], result.Groups[0][2], result.Groups[1][0], result.Groups[1][2]}
+	recovered, err := app.RecoverSLIP39Shares(shares, "TREZOR", "NewPassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to recover SLIP-39 shares:", err)
+	}
+	if recovered.Address != result.Address || recovered.Seed == "" {
+		t.Fatalf("❌ Recovered %s, expected %s", recovered.Address, result.Address)
+	}
+
+	key, err := keystore.DecryptKey([]byte(recovered.Keystore), "NewPassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to decrypt recovered keystore:", err)
+	}
+	if key.Address.Hex() != result.Address {
+		t.Fatalf("❌ Recovered keystore address %s, expected %s", key.Address.Hex(), result.Address)
+	}
+	fmt.Println("✅ SUCCESS: Recovered keystore matches original address")
+
+	// 3. A wrong passphrase recovers a different wallet
+	fmt.Println("3. Testing a wrong passphrase...")
+	other, err := app.RecoverSLIP39Shares(shares, "", "NewPassw0rd!")
+	if err != nil {
+		t.Fatal("Failed to recover SLIP-39 shares:", err)
+	}
+	if other.Address == result.Address {
+		t.Error("❌ Wrong passphrase recovered the original wallet!")
+	}
+
+	// 4. Too few shares in a group must fail
+	fmt.Println("4. Testing too few shares...")
+	if _, err := app.RecoverSLIP39Shares(shares[:4], "TREZOR", "NewPassw0rd!"); err == nil {
+		t.Error("❌ Incomplete group was accepted!")
+	} else {
+		fmt.Println("✅ Correctly rejected incomplete group:", err)
+	}
+
+	fmt.Println("\n=== Test Complete ===")
+}